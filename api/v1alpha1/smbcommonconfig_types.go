@@ -17,7 +17,9 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -34,6 +36,11 @@ type SmbCommonConfigSpec struct {
 	// this config will use.
 	// +kubebuilder:validation:Required
 	Network SmbCommonNetworkSpec `json:"network,omitempty"`
+
+	// PodSettings are properties applied to the pods that the operator
+	// creates to host shares associated with this config.
+	// +optional
+	PodSettings *SmbPodSettingsSpec `json:"podSettings,omitempty"`
 }
 
 // SmbCommonNetworkSpec values define networking properties for the services
@@ -46,6 +53,218 @@ type SmbCommonNetworkSpec struct {
 	Publish string `json:"publish,omitempty"`
 }
 
+// SmbPodSettingsSpec values define properties of the pods that will host
+// shares. These may be set on a SmbCommonConfig and overridden on a per-share
+// basis.
+type SmbPodSettingsSpec struct {
+	// NodeSelector is a selector which must match a node's labels for
+	// the pods to be scheduled on that node.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are the pods' tolerations.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity defines the pods' scheduling constraints.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// TopologySpreadConstraints describes how the pods ought to spread
+	// across topology domains.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PriorityClassName indicates the pods' priority.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Resources specifies the compute resources of the containers in
+	// the pods. Unset values default to the operator's configuration.
+	// +optional
+	Resources *SmbContainerResourcesSpec `json:"resources,omitempty"`
+
+	// DisruptionBudget configures the PodDisruptionBudget the operator
+	// creates for the pods.
+	// +optional
+	DisruptionBudget *SmbDisruptionBudgetSpec `json:"disruptionBudget,omitempty"`
+
+	// SecurityContext specifies pod level security attributes of the pods.
+	// +optional
+	SecurityContext *SmbPodSecuritySpec `json:"securityContext,omitempty"`
+
+	// Probes specifies the timing of the health checks of the containers
+	// in the pods.
+	// +optional
+	Probes *SmbProbesSpec `json:"probes,omitempty"`
+
+	// TerminationGracePeriodSeconds is the time, in seconds, the pods are
+	// given to shut down. Most of this time is used to let clients close
+	// their open files before smbd is stopped.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// DebugLevels specifies the debug levels of the samba daemons. Unset
+	// values use the operator's default debug level. Changes are applied
+	// to existing pods by rolling them.
+	// +optional
+	DebugLevels *SmbDebugLevelsSpec `json:"debugLevels,omitempty"`
+
+	// Containers lists additional containers, such as log shippers or
+	// metrics exporters, added to the pods hosting shares. Container names
+	// must not collide with the containers created by the operator.
+	// +optional
+	Containers []corev1.Container `json:"containers,omitempty"`
+
+	// InitContainers lists additional init containers that are run after
+	// the init containers created by the operator.
+	// +optional
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+
+	// Volumes lists additional volumes that may be mounted by the
+	// additional containers. Volume names must not collide with the volumes
+	// created by the operator.
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+}
+
+// SmbDebugLevelsSpec values define the debug levels of the samba daemons
+// hosting shares.
+type SmbDebugLevelsSpec struct {
+	// Smbd is the debug level of smbd. The value uses the syntax of the
+	// smb.conf "log level" parameter: a level optionally followed by
+	// levels for individual debug classes, for example "1 auth:5 vfs:3".
+	// +kubebuilder:validation:Pattern:=`^\s*([0-9]+|[a-z_]+:[0-9]+)(\s+[a-z_]+:[0-9]+)*\s*$`
+	// +optional
+	Smbd string `json:"smbd,omitempty"`
+
+	// Winbindd is the debug level of winbindd, using the same syntax as
+	// Smbd.
+	// +kubebuilder:validation:Pattern:=`^\s*([0-9]+|[a-z_]+:[0-9]+)(\s+[a-z_]+:[0-9]+)*\s*$`
+	// +optional
+	Winbindd string `json:"winbindd,omitempty"`
+
+	// Ctdbd is the debug level of ctdbd. CTDB does not support debug
+	// classes, the value is a single level.
+	// +kubebuilder:validation:Pattern:=`^([0-9]+|ERROR|WARNING|NOTICE|INFO|DEBUG)$`
+	// +optional
+	Ctdbd string `json:"ctdbd,omitempty"`
+}
+
+// SmbProbesSpec values define the timing of the health checks performed
+// on the containers hosting shares. Unset values use the operator's
+// defaults.
+type SmbProbesSpec struct {
+	// Readiness configures the probes that determine if a container is
+	// ready to serve clients.
+	// +optional
+	Readiness *SmbProbeTimingSpec `json:"readiness,omitempty"`
+
+	// Liveness configures the probes that determine if a container
+	// needs to be restarted.
+	// +optional
+	Liveness *SmbProbeTimingSpec `json:"liveness,omitempty"`
+
+	// Startup configures the probes that allow a container time to start,
+	// for example while it establishes its membership in a domain.
+	// +optional
+	Startup *SmbProbeTimingSpec `json:"startup,omitempty"`
+}
+
+// SmbProbeTimingSpec values define the timing of a probe.
+type SmbProbeTimingSpec struct {
+	// InitialDelaySeconds is the number of seconds after the container
+	// has started before the probe is run.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+
+	// PeriodSeconds is how often, in seconds, to run the probe.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+
+	// TimeoutSeconds is the number of seconds after which the probe
+	// times out.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// FailureThreshold is the number of consecutive failures needed for
+	// the probe to be considered failed.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+// SmbPodSecuritySpec values define the pod level security attributes of the
+// pods hosting shares. The operator always restricts the capabilities of
+// the individual containers.
+type SmbPodSecuritySpec struct {
+	// FSGroup is a supplemental group applied to all containers in the
+	// pods. Volumes that support ownership management, such as the share's
+	// PVC, will be owned by this group.
+	// +optional
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+
+	// FSGroupChangePolicy defines the behavior of changing ownership and
+	// permission of volumes when FSGroup is set. Defaults to OnRootMismatch.
+	// +kubebuilder:validation:Enum:=OnRootMismatch;Always
+	// +optional
+	FSGroupChangePolicy *corev1.PodFSGroupChangePolicy `json:"fsGroupChangePolicy,omitempty"`
+
+	// SeccompProfile is the seccomp profile used by the containers in the
+	// pods. Defaults to the container runtime's default profile.
+	// +optional
+	SeccompProfile *corev1.SeccompProfile `json:"seccompProfile,omitempty"`
+}
+
+// SmbDisruptionBudgetSpec values define the PodDisruptionBudget of the pods
+// hosting shares.
+type SmbDisruptionBudgetSpec struct {
+	// MaxUnavailable is the number of pods that may be unavailable due to
+	// voluntary disruptions, such as node drains. Only used by shares with
+	// the standard availability mode. Clustered shares always permit only
+	// one pod to be unavailable at a time.
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// SmbContainerResourcesSpec values define the compute resource requirements
+// for each of the components that run in the pods hosting shares.
+type SmbContainerResourcesSpec struct {
+	// Smbd specifies the resources of the smbd container.
+	// +optional
+	Smbd *corev1.ResourceRequirements `json:"smbd,omitempty"`
+
+	// Winbind specifies the resources of the winbind container.
+	// +optional
+	Winbind *corev1.ResourceRequirements `json:"winbind,omitempty"`
+
+	// CTDB specifies the resources of the ctdb containers of clustered
+	// instances.
+	// +optional
+	CTDB *corev1.ResourceRequirements `json:"ctdb,omitempty"`
+
+	// DNSRegister specifies the resources of the dns-register container.
+	// +optional
+	DNSRegister *corev1.ResourceRequirements `json:"dnsRegister,omitempty"`
+
+	// SvcWatch specifies the resources of the svc-watch container.
+	// +optional
+	SvcWatch *corev1.ResourceRequirements `json:"svcWatch,omitempty"`
+
+	// Init specifies the resources of the init containers.
+	// +optional
+	Init *corev1.ResourceRequirements `json:"init,omitempty"`
+
+	// Metrics specifies the resources of the metrics exporter container.
+	// +optional
+	Metrics *corev1.ResourceRequirements `json:"metrics,omitempty"`
+}
+
 // SmbCommonConfigStatus defines the observed state of SmbCommonConfig
 type SmbCommonConfigStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
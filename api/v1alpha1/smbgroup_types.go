@@ -0,0 +1,72 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SmbGroupSpec defines the desired state of SmbGroup
+type SmbGroupSpec struct {
+	// GroupName is the name of the group on the samba server. If left
+	// blank the name of the SmbGroup resource is used.
+	// +kubebuilder:validation:MinLength:=1
+	// +optional
+	GroupName string `json:"groupName,omitempty"`
+
+	// Gid is the numeric id of the group. If left blank an id is assigned
+	// by the samba server.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	Gid int64 `json:"gid,omitempty"`
+
+	// Members lists the names of the SmbUser resources, in the same
+	// namespace, that are members of the group. Users naming the group in
+	// their own groups are members too.
+	// +optional
+	Members []string `json:"members,omitempty"`
+}
+
+// SmbGroupStatus defines the observed state of SmbGroup
+type SmbGroupStatus struct {
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Group",type=string,JSONPath=`.spec.groupName`
+
+// SmbGroup is the Schema for the smbgroups API
+type SmbGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SmbGroupSpec   `json:"spec,omitempty"`
+	Status SmbGroupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SmbGroupList contains a list of SmbGroup
+type SmbGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SmbGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SmbGroup{}, &SmbGroupList{})
+}
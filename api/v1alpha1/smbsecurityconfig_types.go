@@ -66,6 +66,24 @@ type SmbSecurityUsersSpec struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength:=1
 	Key string `json:"key,omitempty"`
+
+	// PlaintextPasswords controls how users given a plaintext password in
+	// the secret are handled. The operator always stores the users in a
+	// secret of its own, with NT hashes in place of the passwords, and
+	// mounts that secret in the pods. With "convert", the default,
+	// plaintext passwords are converted to NT hashes. With "reject" the
+	// secret must only contain NT hashes.
+	// +kubebuilder:validation:Enum:=convert;reject
+	// +optional
+	PlaintextPasswords string `json:"plaintextPasswords,omitempty"`
+
+	// Selector selects the SmbUser and SmbGroup resources, in the namespace
+	// of the security config, that make up the users configuration. When
+	// set, the operator creates and owns the secret named by Secret and
+	// writes the users configuration compiled from the selected resources
+	// to Key. An empty selector selects all users and groups.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 }
 
 // SmbSecurityJoinSpec configures how samba instances are allowed to
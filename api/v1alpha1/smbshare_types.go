@@ -67,8 +67,63 @@ type SmbShareSpec struct {
 	// Scaling specifies parameters relating to how share resources can and
 	// should be scaled.
 	Scaling *SmbShareScalingSpec `json:"scaling,omitempty"`
+
+	// PodSettings specifies properties of the pods hosting this share.
+	// Values set here take precedence over those of the SmbCommonConfig.
+	// +optional
+	PodSettings *SmbPodSettingsSpec `json:"podSettings,omitempty"`
+
+	// Audit enables recording the file operations performed on the share.
+	// +optional
+	Audit *SmbShareAuditSpec `json:"audit,omitempty"`
+
+	// Access restricts the users allowed to connect to the share. If left
+	// blank, every user known to the server may connect.
+	// +optional
+	Access *SmbShareAccessSpec `json:"access,omitempty"`
+}
+
+// SmbShareAccessSpec lists the users and groups allowed to connect to a
+// share. A user may connect if it is listed or is a member of a listed
+// group. In user security mode the names must be defined in the users
+// secret of the security config.
+type SmbShareAccessSpec struct {
+	// Users lists the names of the users allowed to connect.
+	// +optional
+	Users []string `json:"users,omitempty"`
+
+	// Groups lists the names of the groups whose members are allowed to
+	// connect.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
 }
 
+// SmbShareAuditSpec defines which file operations on a share are recorded.
+// Records are written to the log of the smbd container.
+type SmbShareAuditSpec struct {
+	// Operations lists the names of the operations to record, as known to
+	// the samba vfs_full_audit module. For example: connect, disconnect,
+	// openat, read, pread, write, pwrite, renameat, unlinkat, mkdirat. The
+	// name "all" records every operation.
+	// +kubebuilder:validation:MinItems:=1
+	Operations []SmbAuditOperation `json:"operations"`
+
+	// Success controls if successful operations are recorded.
+	// +kubebuilder:default:=true
+	// +optional
+	Success bool `json:"success"`
+
+	// Failure controls if failed operations are recorded.
+	// +kubebuilder:default:=true
+	// +optional
+	Failure bool `json:"failure"`
+}
+
+// SmbAuditOperation is the name of a file operation that can be audited.
+// A leading "!" excludes the operation, which is useful with "all".
+// +kubebuilder:validation:Pattern:=`^!?[a-z_]+$`
+type SmbAuditOperation string
+
 // SmbShareStorageSpec defines how storage is associated with a share.
 type SmbShareStorageSpec struct {
 	// Pvc defines PVC backed storage for this share.
@@ -98,6 +153,50 @@ type SmbShareScalingSpec struct {
 	// MinClusterSize specifies the minimum number of smb server instances
 	// to establish when availabilityMode is "clustered".
 	MinClusterSize int `json:"minClusterSize,omitempty"`
+	// NodeSpread specifies how instances of a clustered share are to be
+	// spread across the cluster.
+	// +optional
+	NodeSpread *SmbShareNodeSpreadSpec `json:"nodeSpread,omitempty"`
+	// StatePVC specifies the PVC holding the shared state of the instances
+	// of a clustered share. Values set here take precedence over the
+	// operator's defaults.
+	// +optional
+	StatePVC *SmbShareStatePVCSpec `json:"statePVC,omitempty"`
+}
+
+// SmbShareStatePVCSpec defines the PVC holding the shared state of a
+// clustered share. The PVC is shared by all the instances of the share, so
+// it must be ReadWriteMany. The PVC is created with the share and these
+// values are not applied to an existing PVC.
+type SmbShareStatePVCSpec struct {
+	// StorageClassName is the name of the storage class of the PVC.
+	// +kubebuilder:validation:MinLength:=1
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// AccessModes are the access modes of the PVC. They must include
+	// ReadWriteMany.
+	// +kubebuilder:validation:MinItems:=1
+	// +optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// VolumeMode is the volume mode of the PVC. The PVC is mounted as a
+	// filesystem, so only Filesystem is supported.
+	// +kubebuilder:validation:Enum:=Filesystem
+	// +optional
+	VolumeMode *corev1.PersistentVolumeMode `json:"volumeMode,omitempty"`
+}
+
+// SmbShareNodeSpreadSpec defines how instances of a clustered share are
+// placed relative to each other.
+type SmbShareNodeSpreadSpec struct {
+	// Enabled controls if instances are required to run in distinct
+	// topology domains. Defaults to true.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// TopologyKey is the node label used to define the topology domains
+	// instances are spread over. Defaults to the node's hostname.
+	// +kubebuilder:default:=kubernetes.io/hostname
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
 }
 
 // SmbShareStatus defines the observed state of SmbShare
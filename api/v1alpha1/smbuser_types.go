@@ -0,0 +1,108 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SmbUserSpec defines the desired state of SmbUser
+type SmbUserSpec struct {
+	// UserName is the name of the user on the samba server. If left blank
+	// the name of the SmbUser resource is used.
+	// +kubebuilder:validation:MinLength:=1
+	// +optional
+	UserName string `json:"userName,omitempty"`
+
+	// Password identifies the secret key holding the password of the user.
+	// +optional
+	Password *SmbUserPasswordSpec `json:"password,omitempty"`
+
+	// Uid is the numeric user id of the user. If left blank an id is
+	// assigned by the samba server.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	Uid int64 `json:"uid,omitempty"`
+
+	// Gid is the numeric id of the primary group of the user.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	Gid int64 `json:"gid,omitempty"`
+
+	// Groups lists the names of the SmbGroup resources, in the same
+	// namespace, the user is a member of.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+}
+
+// SmbUserPasswordSpec identifies a secret key holding a password.
+type SmbUserPasswordSpec struct {
+	// Secret is the name of the secret, in the namespace of the SmbUser,
+	// holding the password.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	Secret string `json:"secret,omitempty"`
+
+	// Key identifies the key within the secret holding the password.
+	// +kubebuilder:default:=password
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Generate makes the operator create the secret, owned by the SmbUser,
+	// with a randomly generated password under Key and the user name under
+	// "username". Changing the value of the
+	// samba-operator.samba.org/rotate-password annotation of the SmbUser
+	// generates a new password and restarts the servers of the shares
+	// using it.
+	// +optional
+	Generate bool `json:"generate,omitempty"`
+}
+
+// SmbUserStatus defines the observed state of SmbUser
+type SmbUserStatus struct {
+	// SecurityConfigs lists the names of the SmbSecurityConfig resources
+	// whose users configuration includes the user.
+	// +optional
+	SecurityConfigs []string `json:"securityConfigs,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="User",type=string,JSONPath=`.spec.userName`
+// +kubebuilder:printcolumn:name="Security Configs",type=string,JSONPath=`.status.securityConfigs`
+
+// SmbUser is the Schema for the smbusers API
+type SmbUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SmbUserSpec   `json:"spec,omitempty"`
+	Status SmbUserStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SmbUserList contains a list of SmbUser
+type SmbUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SmbUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SmbUser{}, &SmbUserList{})
+}
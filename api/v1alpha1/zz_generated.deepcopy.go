@@ -22,7 +22,9 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -30,7 +32,7 @@ func (in *SmbCommonConfig) DeepCopyInto(out *SmbCommonConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 }
 
@@ -88,6 +90,11 @@ func (in *SmbCommonConfigList) DeepCopyObject() runtime.Object {
 func (in *SmbCommonConfigSpec) DeepCopyInto(out *SmbCommonConfigSpec) {
 	*out = *in
 	out.Network = in.Network
+	if in.PodSettings != nil {
+		in, out := &in.PodSettings, &out.PodSettings
+		*out = new(SmbPodSettingsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbCommonConfigSpec.
@@ -130,6 +137,372 @@ func (in *SmbCommonNetworkSpec) DeepCopy() *SmbCommonNetworkSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbContainerResourcesSpec) DeepCopyInto(out *SmbContainerResourcesSpec) {
+	*out = *in
+	if in.Smbd != nil {
+		in, out := &in.Smbd, &out.Smbd
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Winbind != nil {
+		in, out := &in.Winbind, &out.Winbind
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CTDB != nil {
+		in, out := &in.CTDB, &out.CTDB
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNSRegister != nil {
+		in, out := &in.DNSRegister, &out.DNSRegister
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SvcWatch != nil {
+		in, out := &in.SvcWatch, &out.SvcWatch
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Init != nil {
+		in, out := &in.Init, &out.Init
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbContainerResourcesSpec.
+func (in *SmbContainerResourcesSpec) DeepCopy() *SmbContainerResourcesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbContainerResourcesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbDebugLevelsSpec) DeepCopyInto(out *SmbDebugLevelsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbDebugLevelsSpec.
+func (in *SmbDebugLevelsSpec) DeepCopy() *SmbDebugLevelsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbDebugLevelsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbDisruptionBudgetSpec) DeepCopyInto(out *SmbDisruptionBudgetSpec) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbDisruptionBudgetSpec.
+func (in *SmbDisruptionBudgetSpec) DeepCopy() *SmbDisruptionBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbDisruptionBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbGroup) DeepCopyInto(out *SmbGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbGroup.
+func (in *SmbGroup) DeepCopy() *SmbGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SmbGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbGroupList) DeepCopyInto(out *SmbGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SmbGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbGroupList.
+func (in *SmbGroupList) DeepCopy() *SmbGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SmbGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbGroupSpec) DeepCopyInto(out *SmbGroupSpec) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbGroupSpec.
+func (in *SmbGroupSpec) DeepCopy() *SmbGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbGroupStatus) DeepCopyInto(out *SmbGroupStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbGroupStatus.
+func (in *SmbGroupStatus) DeepCopy() *SmbGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbPodSecuritySpec) DeepCopyInto(out *SmbPodSecuritySpec) {
+	*out = *in
+	if in.FSGroup != nil {
+		in, out := &in.FSGroup, &out.FSGroup
+		*out = new(int64)
+		**out = **in
+	}
+	if in.FSGroupChangePolicy != nil {
+		in, out := &in.FSGroupChangePolicy, &out.FSGroupChangePolicy
+		*out = new(v1.PodFSGroupChangePolicy)
+		**out = **in
+	}
+	if in.SeccompProfile != nil {
+		in, out := &in.SeccompProfile, &out.SeccompProfile
+		*out = new(v1.SeccompProfile)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbPodSecuritySpec.
+func (in *SmbPodSecuritySpec) DeepCopy() *SmbPodSecuritySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbPodSecuritySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbPodSettingsSpec) DeepCopyInto(out *SmbPodSettingsSpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(SmbContainerResourcesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DisruptionBudget != nil {
+		in, out := &in.DisruptionBudget, &out.DisruptionBudget
+		*out = new(SmbDisruptionBudgetSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(SmbPodSecuritySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(SmbProbesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DebugLevels != nil {
+		in, out := &in.DebugLevels, &out.DebugLevels
+		*out = new(SmbDebugLevelsSpec)
+		**out = **in
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbPodSettingsSpec.
+func (in *SmbPodSettingsSpec) DeepCopy() *SmbPodSettingsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbPodSettingsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbProbeTimingSpec) DeepCopyInto(out *SmbProbeTimingSpec) {
+	*out = *in
+	if in.InitialDelaySeconds != nil {
+		in, out := &in.InitialDelaySeconds, &out.InitialDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbProbeTimingSpec.
+func (in *SmbProbeTimingSpec) DeepCopy() *SmbProbeTimingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbProbeTimingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbProbesSpec) DeepCopyInto(out *SmbProbesSpec) {
+	*out = *in
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(SmbProbeTimingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Liveness != nil {
+		in, out := &in.Liveness, &out.Liveness
+		*out = new(SmbProbeTimingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Startup != nil {
+		in, out := &in.Startup, &out.Startup
+		*out = new(SmbProbeTimingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbProbesSpec.
+func (in *SmbProbesSpec) DeepCopy() *SmbProbesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbProbesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SmbSecurityConfig) DeepCopyInto(out *SmbSecurityConfig) {
 	*out = *in
@@ -195,7 +568,7 @@ func (in *SmbSecurityConfigSpec) DeepCopyInto(out *SmbSecurityConfigSpec) {
 	if in.Users != nil {
 		in, out := &in.Users, &out.Users
 		*out = new(SmbSecurityUsersSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.JoinSources != nil {
 		in, out := &in.JoinSources, &out.JoinSources
@@ -309,6 +682,11 @@ func (in *SmbSecurityUserJoinSpec) DeepCopy() *SmbSecurityUserJoinSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SmbSecurityUsersSpec) DeepCopyInto(out *SmbSecurityUsersSpec) {
 	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbSecurityUsersSpec.
@@ -348,6 +726,51 @@ func (in *SmbShare) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbShareAccessSpec) DeepCopyInto(out *SmbShareAccessSpec) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbShareAccessSpec.
+func (in *SmbShareAccessSpec) DeepCopy() *SmbShareAccessSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbShareAccessSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbShareAuditSpec) DeepCopyInto(out *SmbShareAuditSpec) {
+	*out = *in
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]SmbAuditOperation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbShareAuditSpec.
+func (in *SmbShareAuditSpec) DeepCopy() *SmbShareAuditSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbShareAuditSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SmbShareList) DeepCopyInto(out *SmbShareList) {
 	*out = *in
@@ -380,6 +803,26 @@ func (in *SmbShareList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbShareNodeSpreadSpec) DeepCopyInto(out *SmbShareNodeSpreadSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbShareNodeSpreadSpec.
+func (in *SmbShareNodeSpreadSpec) DeepCopy() *SmbShareNodeSpreadSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbShareNodeSpreadSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SmbSharePvcSpec) DeepCopyInto(out *SmbSharePvcSpec) {
 	*out = *in
@@ -403,6 +846,16 @@ func (in *SmbSharePvcSpec) DeepCopy() *SmbSharePvcSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SmbShareScalingSpec) DeepCopyInto(out *SmbShareScalingSpec) {
 	*out = *in
+	if in.NodeSpread != nil {
+		in, out := &in.NodeSpread, &out.NodeSpread
+		*out = new(SmbShareNodeSpreadSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StatePVC != nil {
+		in, out := &in.StatePVC, &out.StatePVC
+		*out = new(SmbShareStatePVCSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbShareScalingSpec.
@@ -422,7 +875,22 @@ func (in *SmbShareSpec) DeepCopyInto(out *SmbShareSpec) {
 	if in.Scaling != nil {
 		in, out := &in.Scaling, &out.Scaling
 		*out = new(SmbShareScalingSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSettings != nil {
+		in, out := &in.PodSettings, &out.PodSettings
+		*out = new(SmbPodSettingsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = new(SmbShareAuditSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Access != nil {
+		in, out := &in.Access, &out.Access
+		*out = new(SmbShareAccessSpec)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -436,6 +904,31 @@ func (in *SmbShareSpec) DeepCopy() *SmbShareSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbShareStatePVCSpec) DeepCopyInto(out *SmbShareStatePVCSpec) {
+	*out = *in
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumeMode != nil {
+		in, out := &in.VolumeMode, &out.VolumeMode
+		*out = new(v1.PersistentVolumeMode)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbShareStatePVCSpec.
+func (in *SmbShareStatePVCSpec) DeepCopy() *SmbShareStatePVCSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbShareStatePVCSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SmbShareStatus) DeepCopyInto(out *SmbShareStatus) {
 	*out = *in
@@ -470,3 +963,122 @@ func (in *SmbShareStorageSpec) DeepCopy() *SmbShareStorageSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbUser) DeepCopyInto(out *SmbUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbUser.
+func (in *SmbUser) DeepCopy() *SmbUser {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SmbUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbUserList) DeepCopyInto(out *SmbUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SmbUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbUserList.
+func (in *SmbUserList) DeepCopy() *SmbUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SmbUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbUserPasswordSpec) DeepCopyInto(out *SmbUserPasswordSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbUserPasswordSpec.
+func (in *SmbUserPasswordSpec) DeepCopy() *SmbUserPasswordSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbUserPasswordSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbUserSpec) DeepCopyInto(out *SmbUserSpec) {
+	*out = *in
+	if in.Password != nil {
+		in, out := &in.Password, &out.Password
+		*out = new(SmbUserPasswordSpec)
+		**out = **in
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbUserSpec.
+func (in *SmbUserSpec) DeepCopy() *SmbUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SmbUserStatus) DeepCopyInto(out *SmbUserStatus) {
+	*out = *in
+	if in.SecurityConfigs != nil {
+		in, out := &in.SecurityConfigs, &out.SecurityConfigs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SmbUserStatus.
+func (in *SmbUserStatus) DeepCopy() *SmbUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SmbUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
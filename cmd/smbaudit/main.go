@@ -0,0 +1,61 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// smbaudit runs next to smbd, receives the audit records of the samba
+// vfs_full_audit module over syslog and prints them as JSON objects.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/samba-in-kubernetes/samba-operator/internal/auditlog"
+)
+
+var (
+	// Version of the software at compile time.
+	Version = "(unset)"
+	// CommitID of the revision used to compile the software.
+	CommitID = "(unset)"
+)
+
+func main() {
+	var socketPath string
+	flag.StringVar(
+		&socketPath, "socket", "/run/samba-audit/log",
+		"The path of the syslog socket smbd sends the records to.")
+	flag.Parse()
+
+	log.Printf("smbaudit version %s (%s)", Version, CommitID)
+	ctx, cancel := signal.NotifyContext(
+		context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	conn, err := auditlog.Listen(socketPath)
+	if err != nil {
+		log.Fatalf("failed to listen on %s: %v", socketPath, err)
+	}
+	err = auditlog.Serve(ctx, conn, os.Stdout, func(err error) {
+		log.Printf("dropped message: %v", err)
+	})
+	if err != nil {
+		log.Fatalf("failed to receive audit records: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// smbmetrics runs next to smbd and exposes the status of the samba server
+// as Prometheus metrics.
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	flag "github.com/spf13/pflag"
+
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbmetrics"
+)
+
+var (
+	// Version of the software at compile time.
+	Version = "(unset)"
+	// CommitID of the revision used to compile the software.
+	CommitID = "(unset)"
+)
+
+func main() {
+	var (
+		listenAddr string
+		interval   time.Duration
+		clustered  bool
+	)
+	flag.StringVar(
+		&listenAddr, "listen", ":8080",
+		"The address the metrics endpoint binds to.")
+	flag.DurationVar(
+		&interval, "interval", 15*time.Second,
+		"How often the status of the server is gathered.")
+	flag.BoolVar(
+		&clustered, "clustered", false,
+		"Also gather the status of the CTDB cluster.")
+	flag.Parse()
+
+	log.Printf("smbmetrics version %s (%s)", Version, CommitID)
+	ctx, cancel := signal.NotifyContext(
+		context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	collector := smbmetrics.NewCollector(smbmetrics.ExecRunner, clustered)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	go collector.Run(ctx, interval, func(err error) {
+		log.Printf("failed to gather server status: %v", err)
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(
+		registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Addr: listenAddr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatalf("metrics server failed: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"os"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+)
+
+// ConfigReloader reloads the operator configuration when its sources
+// change. When the configuration changes all SmbShares are reconciled
+// again, so that the new configuration is applied to them.
+type ConfigReloader struct {
+	client.Client
+	Log    logr.Logger
+	Source *conf.Source
+	// ConfigMap optionally names a ConfigMap, in the working namespace,
+	// holding configuration values.
+	ConfigMap string
+
+	elected <-chan struct{}
+	events  chan event.GenericEvent
+	// triggers is signalled when the ConfigMap or the configuration file
+	// changes. It is buffered so that changes made while reloading are not
+	// lost.
+	triggers chan struct{}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// instance of the operator keeps its configuration current, so that an
+// instance becoming the leader does not act on stale configuration.
+func (*ConfigReloader) NeedLeaderElection() bool {
+	return false
+}
+
+// SetupWithManager sets up the reloader. The SmbShare reconciler must be
+// set up afterwards to receive the reconcile requests of the reloader.
+func (r *ConfigReloader) SetupWithManager(
+	mgr ctrl.Manager, shares *SmbShareReconciler) error {
+	// ---
+	r.elected = mgr.Elected()
+	r.events = make(chan event.GenericEvent)
+	r.triggers = make(chan struct{}, 1)
+	shares.reload = r.events
+	if r.ConfigMap != "" {
+		inf, err := mgr.GetCache().GetInformer(
+			context.Background(), &corev1.ConfigMap{})
+		if err != nil {
+			return err
+		}
+		inf.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+			AddFunc:    r.notify,
+			UpdateFunc: func(_, obj interface{}) { r.notify(obj) },
+			DeleteFunc: r.notify,
+		})
+	}
+	return mgr.Add(r)
+}
+
+// Start reloads the configuration until the context is done.
+func (r *ConfigReloader) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	// The directories are watched rather than the file, as a mounted
+	// ConfigMap is updated by replacing a symlink in its directory.
+	for _, dir := range r.Source.ConfigDirs() {
+		if err := watcher.Add(dir); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	for {
+		r.reload(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-watcher.Events:
+		case err := <-watcher.Errors:
+			r.Log.Error(err, "Failed to watch configuration file")
+		case <-r.triggers:
+		}
+	}
+}
+
+func (r *ConfigReloader) notify(obj interface{}) {
+	if d, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm.Name != r.ConfigMap ||
+		cm.Namespace != conf.Get().WorkingNamespace {
+		return
+	}
+	select {
+	case r.triggers <- struct{}{}:
+	default:
+	}
+}
+
+func (r *ConfigReloader) reload(ctx context.Context) {
+	if r.ConfigMap != "" {
+		cm := &corev1.ConfigMap{}
+		key := types.NamespacedName{
+			Namespace: conf.Get().WorkingNamespace,
+			Name:      r.ConfigMap,
+		}
+		err := r.Get(ctx, key, cm)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to get configuration ConfigMap")
+			return
+		}
+		if err = r.Source.SetOverrides(cm.Data); err != nil {
+			r.Log.Error(err, "Invalid configuration ConfigMap",
+				"ConfigMap.Namespace", key.Namespace,
+				"ConfigMap.Name", key.Name)
+			return
+		}
+	}
+	changed, err := conf.Reload(r.Source)
+	if err != nil {
+		r.Log.Error(err, "Failed to reload configuration")
+		return
+	} else if !changed {
+		return
+	}
+	r.Log.Info("Reloaded configuration")
+	select {
+	case <-r.elected:
+	default:
+		// only the leader reconciles shares
+		return
+	}
+	shares := &sambaoperatorv1alpha1.SmbShareList{}
+	if err := r.List(ctx, shares); err != nil {
+		r.Log.Error(err, "Failed to list SmbShares")
+		return
+	}
+	for i := range shares.Items {
+		select {
+		case r.events <- event.GenericEvent{Object: &shares.Items[i]}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/resources"
+)
+
+//revive:disable kubebuilder directives
+
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+//revive:enable
+
+// WatchedNamespaces returns the namespaces the operator should watch, as
+// chosen by the WatchNamespaces or WatchNamespaceSelector configuration
+// values. The working namespace is always included. An empty result means
+// that all namespaces are watched.
+func WatchedNamespaces(
+	ctx context.Context,
+	reader client.Reader,
+	cfg *conf.OperatorConfig) ([]string, error) {
+	// ---
+	names := map[string]bool{}
+	switch {
+	case len(cfg.WatchNamespaces) > 0:
+		for _, ns := range cfg.WatchNamespaces {
+			names[ns] = true
+		}
+	case cfg.WatchNamespaceSelector != "":
+		sel, err := labels.Parse(cfg.WatchNamespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+		l := &corev1.NamespaceList{}
+		err = reader.List(ctx, l, client.MatchingLabelsSelector{Selector: sel})
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range l.Items {
+			names[ns.Name] = true
+		}
+	default:
+		return nil, nil
+	}
+	names[cfg.WorkingNamespace] = true
+	result := make([]string, 0, len(names))
+	for ns := range names {
+		result = append(result, ns)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// enqueueAnnotatedOwner enqueues the SmbShare owning an object created
+// outside of the namespace of the share.
+var enqueueAnnotatedOwner = handler.EnqueueRequestsFromMapFunc(
+	func(obj client.Object) []reconcile.Request {
+		nsname, found := resources.OwnerFromAnnotation(obj)
+		if !found {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: nsname}}
+	})
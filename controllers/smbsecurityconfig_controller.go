@@ -20,41 +20,140 @@ import (
 	"context"
 
 	"github.com/go-logr/logr"
-	"k8s.io/apimachinery/pkg/runtime"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/resources"
 )
 
 // SmbSecurityConfigReconciler reconciles a SmbSecurityConfig object
 type SmbSecurityConfigReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	recorder record.EventRecorder
 }
 
 //revive:disable kubebuilder directives
 
 // +kubebuilder:rbac:groups=samba-operator.samba.org,resources=smbsecurityconfigs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=samba-operator.samba.org,resources=smbsecurityconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=samba-operator.samba.org,resources=smbsecurityconfigs/finalizers,verbs=get;update;patch
+// +kubebuilder:rbac:groups=samba-operator.samba.org,resources=smbusers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=samba-operator.samba.org,resources=smbusers/finalizers,verbs=get;update;patch
+// +kubebuilder:rbac:groups=samba-operator.samba.org,resources=smbusers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=samba-operator.samba.org,resources=smbgroups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 //revive:enable
 
 // Reconcile the SmbSecurityConfig resource.
 func (r *SmbSecurityConfigReconciler) Reconcile(
-	_ context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	// ---
-	_ = r.Log.WithValues("smbsecurityconfig", req.NamespacedName)
+	reqLogger := r.Log.WithValues("smbsecurityconfig", req.NamespacedName)
+	reqLogger.Info("Reconciling SmbSecurityConfig")
+
+	manager := resources.NewSmbSecurityConfigManager(
+		r, r.Scheme(), r.recorder, reqLogger)
+	res := manager.Process(ctx, req.NamespacedName)
+	err := res.Err()
+	if res.Requeue() {
+		return ctrl.Result{Requeue: true}, err
+	}
+	return ctrl.Result{}, err
+}
 
-	// your logic here
+// passwordSecretField indexes SmbUsers by the name of their password
+// secret.
+const passwordSecretField = "spec.password.secret"
 
-	return ctrl.Result{}, nil
+func indexPasswordSecret(obj client.Object) []string {
+	u := obj.(*sambaoperatorv1alpha1.SmbUser)
+	if u.Spec.Password == nil || u.Spec.Password.Secret == "" {
+		return nil
+	}
+	return []string{u.Spec.Password.Secret}
 }
 
 // SetupWithManager sets up the reconciler.
 func (r *SmbSecurityConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.recorder == nil {
+		r.recorder = mgr.GetEventRecorderFor("smbsecurityconfig-controller")
+	}
+	err := mgr.GetFieldIndexer().IndexField(context.Background(),
+		&sambaoperatorv1alpha1.SmbUser{},
+		passwordSecretField,
+		indexPasswordSecret)
+	if err != nil {
+		return err
+	}
+	// users, groups and their password secrets are compiled into the users
+	// secret of the security configs selecting them. only the metadata of
+	// secrets is cached.
+	compiling := handler.EnqueueRequestsFromMapFunc(r.configsCompilingUsers)
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&sambaoperatorv1alpha1.SmbSecurityConfig{}).
+		Watches(&source.Kind{Type: &sambaoperatorv1alpha1.SmbUser{}}, compiling).
+		Watches(&source.Kind{Type: &sambaoperatorv1alpha1.SmbGroup{}}, compiling).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.configsCompilingSecret),
+			builder.OnlyMetadata).
 		Complete(r)
 }
+
+// configsCompilingSecret returns requests for the SmbSecurityConfigs
+// compiling users when the secret is the password secret of an SmbUser.
+func (r *SmbSecurityConfigReconciler) configsCompilingSecret(
+	obj client.Object) []reconcile.Request {
+	// ---
+	users := &sambaoperatorv1alpha1.SmbUserList{}
+	err := r.List(context.Background(), users,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{passwordSecretField: obj.GetName()})
+	if err != nil {
+		r.Log.Error(err, "Failed to list SmbUsers")
+		return nil
+	}
+	if len(users.Items) == 0 {
+		return nil
+	}
+	return r.configsCompilingUsers(obj)
+}
+
+// configsCompilingUsers returns requests for the SmbSecurityConfigs, in the
+// namespace of the object, whose users configuration is compiled from
+// SmbUser and SmbGroup resources.
+func (r *SmbSecurityConfigReconciler) configsCompilingUsers(
+	obj client.Object) []reconcile.Request {
+	// ---
+	configs := &sambaoperatorv1alpha1.SmbSecurityConfigList{}
+	err := r.List(context.Background(), configs,
+		client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		r.Log.Error(err, "Failed to list SmbSecurityConfigs")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, sc := range configs.Items {
+		if sc.Spec.Users == nil || sc.Spec.Users.Selector == nil {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: sc.Namespace,
+				Name:      sc.Name,
+			},
+		})
+	}
+	return requests
+}
@@ -22,11 +22,19 @@ import (
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
 	"github.com/samba-in-kubernetes/samba-operator/internal/resources"
 )
 
@@ -35,6 +43,9 @@ type SmbShareReconciler struct {
 	client.Client
 	Log      logr.Logger
 	recorder record.EventRecorder
+	// reload receives SmbShares to reconcile after the operator
+	// configuration changed.
+	reload <-chan event.GenericEvent
 }
 
 //revive:disable kubebuilder directives
@@ -48,7 +59,9 @@ type SmbShareReconciler struct {
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=core,resources=events,verbs=create
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 
 //revive:enable
 
@@ -75,12 +88,97 @@ func (r *SmbShareReconciler) setRecorder(mgr ctrl.Manager) {
 	}
 }
 
+// usersSecretField indexes SmbSecurityConfigs by the name of their users
+// secret.
+const usersSecretField = "spec.users.secret"
+
+func indexUsersSecret(obj client.Object) []string {
+	sc := obj.(*sambaoperatorv1alpha1.SmbSecurityConfig)
+	if sc.Spec.Users == nil || sc.Spec.Users.Secret == "" {
+		return nil
+	}
+	return []string{sc.Spec.Users.Secret}
+}
+
 // SetupWithManager sets up resource management.
 func (r *SmbShareReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.setRecorder(mgr)
-	return ctrl.NewControllerManagedBy(mgr).
+	err := mgr.GetFieldIndexer().IndexField(context.Background(),
+		&sambaoperatorv1alpha1.SmbSecurityConfig{},
+		usersSecretField,
+		indexUsersSecret)
+	if err != nil {
+		return err
+	}
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&sambaoperatorv1alpha1.SmbShare{}).
 		Owns(&corev1.PersistentVolumeClaim{}).
 		Owns(&appsv1.Deployment{}).
-		Complete(r)
+		Owns(&policyv1.PodDisruptionBudget{})
+	if cfg := conf.Get(); cfg != nil &&
+		cfg.ServerNamespaceMode == conf.ServerNamespaceWorking {
+		// resources in the working namespace can not be owned by shares in
+		// other namespaces and are matched to them by annotation
+		owned := []client.Object{
+			&corev1.PersistentVolumeClaim{},
+			&appsv1.Deployment{},
+			&policyv1.PodDisruptionBudget{},
+		}
+		for _, obj := range owned {
+			b = b.Watches(&source.Kind{Type: obj}, enqueueAnnotatedOwner)
+		}
+	}
+	// the users secret of a share is converted into a secret of the
+	// operator. only the metadata of secrets is cached.
+	b = b.Watches(
+		&source.Kind{Type: &corev1.Secret{}},
+		handler.EnqueueRequestsFromMapFunc(r.sharesUsingSecret),
+		builder.OnlyMetadata)
+	if r.reload != nil {
+		b = b.Watches(
+			&source.Channel{Source: r.reload},
+			&handler.EnqueueRequestForObject{})
+	}
+	return b.Complete(r)
+}
+
+// sharesUsingSecret returns requests for the SmbShares whose security
+// config names the secret as its users secret.
+func (r *SmbShareReconciler) sharesUsingSecret(
+	obj client.Object) []reconcile.Request {
+	// ---
+	ctx := context.Background()
+	configs := &sambaoperatorv1alpha1.SmbSecurityConfigList{}
+	err := r.List(ctx, configs,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{usersSecretField: obj.GetName()})
+	if err != nil {
+		r.Log.Error(err, "Failed to list SmbSecurityConfigs")
+		return nil
+	}
+	names := map[string]bool{}
+	for _, sc := range configs.Items {
+		names[sc.Name] = true
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	shares := &sambaoperatorv1alpha1.SmbShareList{}
+	err = r.List(ctx, shares, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		r.Log.Error(err, "Failed to list SmbShares")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, share := range shares.Items {
+		if names[share.Spec.SecurityConfig] {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: share.Namespace,
+					Name:      share.Name,
+				},
+			})
+		}
+	}
+	return requests
 }
@@ -0,0 +1,94 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package auditlog receives the records of the samba vfs_full_audit module
+// and re-encodes them as JSON objects.
+package auditlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Prefix is the vfs_full_audit prefix the records are expected to start
+// with: the user, domain, client address, client machine name and share,
+// separated by "|". vfs_full_audit appends the operation, the result and
+// the arguments of the operation, also separated by "|".
+const Prefix = "%u|%D|%I|%m|%S"
+
+// prefixFields is the number of fields of Prefix.
+const prefixFields = 5
+
+// Record is an audit record of a file operation.
+type Record struct {
+	User      string   `json:"user"`
+	Domain    string   `json:"domain"`
+	Client    string   `json:"client"`
+	Machine   string   `json:"machine"`
+	Share     string   `json:"share"`
+	Operation string   `json:"operation"`
+	Success   bool     `json:"success"`
+	Result    string   `json:"result"`
+	Args      []string `json:"args"`
+}
+
+// ParseRecord parses a record logged by vfs_full_audit. The header of a
+// syslog message preceding the record, as sent by the syslog function of
+// the C library, is skipped.
+func ParseRecord(msg string) (*Record, error) {
+	line := strings.TrimRight(stripSyslogHeader(msg), "\n")
+	fields := strings.Split(line, "|")
+	if len(fields) < prefixFields+2 {
+		return nil, fmt.Errorf("not an audit record: %q", line)
+	}
+	r := &Record{
+		User:      fields[0],
+		Domain:    fields[1],
+		Client:    fields[2],
+		Machine:   fields[3],
+		Share:     fields[4],
+		Operation: fields[5],
+		Result:    fields[6],
+		Args:      fields[7:],
+	}
+	r.Success = r.Result == "ok"
+	if r.Args == nil {
+		r.Args = []string{}
+	}
+	return r, nil
+}
+
+// stripSyslogHeader removes the "<PRI>TIMESTAMP TAG[PID]: " header of a
+// syslog message, if present.
+func stripSyslogHeader(msg string) string {
+	if !strings.HasPrefix(msg, "<") {
+		return msg
+	}
+	end := strings.Index(msg, "]: ")
+	if end < 0 {
+		return msg
+	}
+	return msg[end+len("]: "):]
+}
+
+// Encode returns the record as a single line JSON object.
+func (r *Record) Encode() ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
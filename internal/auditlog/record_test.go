@@ -0,0 +1,132 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleRecord = `<173>Oct 19 11:32:18 smbd_audit[1234]: ` +
+	`alice|EXAMPLE|10.1.2.3|laptop1|audited|renameat|ok|` +
+	`/share/"quoted" \ name.txt|/share/new	name.txt`
+
+func TestParseRecord(t *testing.T) {
+	r, err := ParseRecord(sampleRecord)
+	require.NoError(t, err)
+	assert.Equal(t, &Record{
+		User:      "alice",
+		Domain:    "EXAMPLE",
+		Client:    "10.1.2.3",
+		Machine:   "laptop1",
+		Share:     "audited",
+		Operation: "renameat",
+		Success:   true,
+		Result:    "ok",
+		Args:      []string{`/share/"quoted" \ name.txt`, "/share/new\tname.txt"},
+	}, r)
+
+	r, err = ParseRecord("bob|EXAMPLE|10.1.2.4|laptop2|audited|" +
+		"unlinkat|fail (No such file or directory)|/share/gone\n")
+	require.NoError(t, err)
+	assert.False(t, r.Success)
+	assert.Equal(t, "fail (No such file or directory)", r.Result)
+	assert.Equal(t, []string{"/share/gone"}, r.Args)
+
+	r, err = ParseRecord("bob|EXAMPLE|10.1.2.4|laptop2|audited|disconnect|ok")
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, r.Args)
+
+	_, err = ParseRecord("<30>Oct 19 11:32:18 smbd[12]: not an audit record")
+	assert.Error(t, err)
+}
+
+func TestEncodeRecord(t *testing.T) {
+	r, err := ParseRecord(sampleRecord)
+	require.NoError(t, err)
+	b, err := r.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(b, []byte("\n")))
+
+	decoded := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, "alice", decoded["user"])
+	assert.Equal(t, "renameat", decoded["operation"])
+	assert.Equal(t, true, decoded["success"])
+	assert.Equal(t,
+		[]interface{}{`/share/"quoted" \ name.txt`, "/share/new\tname.txt"},
+		decoded["args"])
+}
+
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestServe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	conn, err := Listen(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	var errs []error
+	done := make(chan error)
+	go func() {
+		done <- Serve(ctx, conn, out, func(err error) {
+			errs = append(errs, err)
+		})
+	}()
+
+	client, err := net.Dial("unixgram", path)
+	require.NoError(t, err)
+	_, err = client.Write([]byte("<30>Oct 19 11:32:18 smbd[12]: hello"))
+	require.NoError(t, err)
+	_, err = client.Write([]byte(sampleRecord))
+	require.NoError(t, err)
+	client.Close()
+
+	assert.Eventually(t, func() bool {
+		return out.String() != ""
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	assert.Len(t, errs, 1)
+	r := &Record{}
+	require.NoError(t, json.Unmarshal([]byte(out.String()), r))
+	assert.Equal(t, "audited", r.Share)
+}
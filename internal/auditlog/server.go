@@ -0,0 +1,84 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditlog
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+)
+
+// maxMessageSize is the size of the largest syslog message received.
+// Longer messages are truncated.
+const maxMessageSize = 64 * 1024
+
+// Listen creates a unix datagram socket at path, replacing any socket
+// left by a previous run, for syslog messages to be sent to.
+func Listen(path string) (*net.UnixConn, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	conn, err := net.ListenUnixgram(
+		"unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	// smbd runs as root, but anyone allowed to log may send messages
+	if err := os.Chmod(path, 0666); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Serve reads audit records from conn and writes them to w as JSON
+// objects, one per line, until the context is done. Messages that are not
+// audit records are passed to onError.
+func Serve(
+	ctx context.Context,
+	conn net.PacketConn,
+	w io.Writer,
+	onError func(error)) error {
+	// ---
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		r, err := ParseRecord(string(buf[:n]))
+		if err != nil {
+			onError(err)
+			continue
+		}
+		b, err := r.Encode()
+		if err != nil {
+			onError(err)
+			continue
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+}
@@ -1,11 +1,22 @@
 package conf
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
+	"sync"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
 )
 
 // OperatorConfig is a type holding general configuration values.
@@ -17,6 +28,20 @@ type OperatorConfig struct {
 	// SvcWatchContainerImage can be used to select alternate container image
 	// for the service watch utility.
 	SvcWatchContainerImage string `mapstructure:"svc-watch-container-image"`
+	// WinbindContainerImage, CTDBContainerImage, DNSRegisterContainerImage
+	// and InitContainerImage select the images of the other samba
+	// containers. If unset the SmbdContainerImage is used.
+	WinbindContainerImage     string `mapstructure:"winbind-container-image"`
+	CTDBContainerImage        string `mapstructure:"ctdb-container-image"`
+	DNSRegisterContainerImage string `mapstructure:"dns-register-container-image"`
+	InitContainerImage        string `mapstructure:"init-container-image"`
+	// ImagePullPolicy sets the pull policy of all containers. If unset the
+	// Kubernetes default is used.
+	ImagePullPolicy corev1.PullPolicy `mapstructure:"image-pull-policy"`
+	// ImagePullSecrets lists the names of secrets, in the namespace of the
+	// pods, used to pull the container images. It is specified as a comma
+	// separated list.
+	ImagePullSecrets []string `mapstructure:"image-pull-secrets"`
 	// SmbdContainerName can be used to set the name of the primary container,
 	// the one running smbd, in the pod.
 	SmbdContainerName string `mapstructure:"smbd-container-name"`
@@ -26,34 +51,348 @@ type OperatorConfig struct {
 	// WorkingNamespace defines the namespace the operator will (generally)
 	// make changes in.
 	WorkingNamespace string `mapstructure:"working-namespace"`
+	// WatchNamespaces lists the namespaces the operator watches for
+	// SmbShares, specified as a comma separated list. If unset, and no
+	// WatchNamespaceSelector is set, all namespaces are watched.
+	WatchNamespaces []string `mapstructure:"watch-namespaces"`
+	// WatchNamespaceSelector is a label selector choosing the namespaces
+	// the operator watches for SmbShares. The namespaces are selected when
+	// the operator starts.
+	WatchNamespaceSelector string `mapstructure:"watch-namespace-selector"`
+	// ServerNamespaceMode indicates the namespace the resources hosting a
+	// share are created in.
+	ServerNamespaceMode ServerNamespaceMode `mapstructure:"server-namespace-mode"`
 	// SambaDebugLevel can be used to set debugging level for samba
 	// components in deployed containers.
 	SambaDebugLevel string `mapstructure:"samba-debug-level"`
-	// StatePVCSize is a (string) value that indicates how large the operator
-	// should request shared state (not data!) PVCs.
-	StatePVCSize string `mapstructure:"state-pvc-size"`
-	// ClusterSupport is a (string) value that indicates if the operator
-	// will be allowed to set up clustered instances.
-	ClusterSupport string `mapstructure:"cluster-support"`
+	// StatePVCSize indicates how large the operator should request shared
+	// state (not data!) PVCs.
+	StatePVCSize resource.Quantity `mapstructure:"state-pvc-size"`
+	// StatePVCStorageClass is the storage class of shared state PVCs. If
+	// unset the cluster's default storage class is used.
+	StatePVCStorageClass string `mapstructure:"state-pvc-storage-class"`
+	// StatePVCAccessModes are the access modes of shared state PVCs,
+	// specified as a comma separated list.
+	StatePVCAccessModes []corev1.PersistentVolumeAccessMode `mapstructure:"state-pvc-access-modes"`
+	// StatePVCVolumeMode is the volume mode of shared state PVCs. If unset
+	// the Kubernetes default is used. Only Filesystem is supported.
+	StatePVCVolumeMode corev1.PersistentVolumeMode `mapstructure:"state-pvc-volume-mode"`
+	// ClusterSupport indicates if the operator will be allowed to set up
+	// clustered instances.
+	ClusterSupport ClusterSupportMode `mapstructure:"cluster-support"`
+	// SmbdResourceRequests and SmbdResourceLimits are the default compute
+	// resources for the smbd container. In the environment, on the CLI and
+	// in ConfigMaps they are specified as comma separated name=quantity
+	// pairs, for example: "cpu=100m,memory=128Mi".
+	SmbdResourceRequests corev1.ResourceList `mapstructure:"smbd-resource-requests"`
+	SmbdResourceLimits   corev1.ResourceList `mapstructure:"smbd-resource-limits"`
+	// WinbindResourceRequests and WinbindResourceLimits are the default
+	// compute resources for the winbind container.
+	WinbindResourceRequests corev1.ResourceList `mapstructure:"winbind-resource-requests"`
+	WinbindResourceLimits   corev1.ResourceList `mapstructure:"winbind-resource-limits"`
+	// CTDBResourceRequests and CTDBResourceLimits are the default
+	// compute resources for the ctdb containers.
+	CTDBResourceRequests corev1.ResourceList `mapstructure:"ctdb-resource-requests"`
+	CTDBResourceLimits   corev1.ResourceList `mapstructure:"ctdb-resource-limits"`
+	// DNSRegisterResourceRequests and DNSRegisterResourceLimits are the
+	// default compute resources for the dns-register container.
+	DNSRegisterResourceRequests corev1.ResourceList `mapstructure:"dns-register-resource-requests"`
+	DNSRegisterResourceLimits   corev1.ResourceList `mapstructure:"dns-register-resource-limits"`
+	// SvcWatchResourceRequests and SvcWatchResourceLimits are the default
+	// compute resources for the svc-watch container.
+	SvcWatchResourceRequests corev1.ResourceList `mapstructure:"svc-watch-resource-requests"`
+	SvcWatchResourceLimits   corev1.ResourceList `mapstructure:"svc-watch-resource-limits"`
+	// InitResourceRequests and InitResourceLimits are the default compute
+	// resources for the init containers.
+	InitResourceRequests corev1.ResourceList `mapstructure:"init-resource-requests"`
+	InitResourceLimits   corev1.ResourceList `mapstructure:"init-resource-limits"`
+	// MetricsResourceRequests and MetricsResourceLimits are the default
+	// compute resources for the metrics exporter container.
+	MetricsResourceRequests corev1.ResourceList `mapstructure:"metrics-resource-requests"`
+	MetricsResourceLimits   corev1.ResourceList `mapstructure:"metrics-resource-limits"`
+	// MetricsExporterMode indicates if the operator adds a metrics exporter
+	// container to the pods hosting shares.
+	MetricsExporterMode MetricsExporterMode `mapstructure:"metrics-exporter-mode"`
+	// MetricsContainerImage selects the image of the metrics exporter.
+	MetricsContainerImage string `mapstructure:"metrics-container-image"`
+	// SambaContainerConfigVersion is the newest schema version of the
+	// samba container config supported by the samba container images.
+	// Configs are stored in this version. Group memberships of local users
+	// require version v1.
+	SambaContainerConfigVersion string `mapstructure:"samba-container-config-version"`
+
+	// decodeErrors lists the values that could not be converted to the
+	// type of their field. They are reported by Validate.
+	decodeErrors []string
 }
 
+// ClusterSupportMode indicates if the operator may set up clustered
+// instances.
+type ClusterSupportMode string
+
+const (
+	// ClusterSupportDisabled does not allow clustered instances.
+	ClusterSupportDisabled ClusterSupportMode = ""
+	// ClusterSupportCTDBExperimental allows clustered instances using
+	// CTDB. The support is experimental.
+	ClusterSupportCTDBExperimental ClusterSupportMode = "ctdb-is-experimental"
+)
+
+// MetricsExporterMode indicates if the operator adds a metrics exporter
+// container to the pods hosting shares.
+type MetricsExporterMode string
+
+const (
+	// MetricsExporterDisabled does not add the metrics exporter.
+	MetricsExporterDisabled MetricsExporterMode = "disabled"
+	// MetricsExporterEnabled adds the metrics exporter.
+	MetricsExporterEnabled MetricsExporterMode = "enabled"
+)
+
+// ServerNamespaceMode indicates the namespace the resources hosting a
+// share are created in.
+type ServerNamespaceMode string
+
+const (
+	// ServerNamespaceShare creates the resources in the namespace of the
+	// SmbShare.
+	ServerNamespaceShare ServerNamespaceMode = "share-namespace"
+	// ServerNamespaceWorking creates the resources in the working
+	// namespace of the operator.
+	ServerNamespaceWorking ServerNamespaceMode = "working-namespace"
+)
+
+// ValidationError lists all the problems found in an OperatorConfig.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid operator configuration: %s",
+		strings.Join(e.Problems, "; "))
+}
+
+// debugLevelPattern matches the syntax of the smb.conf "log level"
+// parameter: a level optionally followed by levels of debug classes.
+var debugLevelPattern = regexp.MustCompile(
+	`^\s*([0-9]+|[a-z_]+:[0-9]+)(\s+[a-z_]+:[0-9]+)*\s*$`)
+
+// imageRefPattern matches container image references: an optional registry
+// host and port, a path of lower case components, an optional tag and an
+// optional digest. It is a simplified form of the reference grammar of the
+// container registries.
+var imageRefPattern = func() *regexp.Regexp {
+	label := `[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?`
+	domain := label + `(\.` + label + `)*(:[0-9]+)?`
+	component := `[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*`
+	tag := `[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}`
+	digest := `[a-z0-9]+([+._-][a-z0-9]+)*:[a-fA-F0-9]{32,}`
+	return regexp.MustCompile(
+		`^(` + domain + `/)?` + component + `(/` + component + `)*` +
+			`(:` + tag + `)?(@` + digest + `)?$`)
+}()
+
 // Validate the OperatorConfig returning an error if the config is not
 // directly usable by the operator. This may occur if certain required
-// values are unset or invalid.
+// values are unset or invalid. All problems found are reported in a single
+// ValidationError.
 func (oc *OperatorConfig) Validate() error {
+	problems := append([]string(nil), oc.decodeErrors...)
+	invalid := func(name string, value interface{}, reason string) {
+		p := fmt.Sprintf("%s value [%v] invalid", name, value)
+		if reason != "" {
+			p += ": " + reason
+		}
+		problems = append(problems, p)
+	}
+
 	// Ensure that WorkingNamespace is set. We don't default it to anything.
 	// It must be passed in, typically by the operator's own pod spec.
 	if oc.WorkingNamespace == "" {
-		return fmt.Errorf(
-			"WorkingNamespace value [%s] invalid", oc.WorkingNamespace)
+		invalid("WorkingNamespace", oc.WorkingNamespace, "")
+	}
+	for _, ns := range oc.WatchNamespaces {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			invalid("WatchNamespaces", ns, strings.Join(errs, ", "))
+		}
+	}
+	if oc.WatchNamespaceSelector != "" {
+		if _, err := labels.Parse(oc.WatchNamespaceSelector); err != nil {
+			invalid("WatchNamespaceSelector", oc.WatchNamespaceSelector,
+				err.Error())
+		}
+		if len(oc.WatchNamespaces) > 0 {
+			invalid("WatchNamespaceSelector", oc.WatchNamespaceSelector,
+				"can not be combined with WatchNamespaces")
+		}
+	}
+	switch oc.ServerNamespaceMode {
+	case "", ServerNamespaceShare, ServerNamespaceWorking:
+	default:
+		invalid("ServerNamespaceMode", oc.ServerNamespaceMode,
+			fmt.Sprintf("expected %q or %q",
+				ServerNamespaceShare, ServerNamespaceWorking))
+	}
+	containerNames := []struct {
+		name, value string
+	}{
+		{"SmbdContainerName", oc.SmbdContainerName},
+		{"WinbindContainerName", oc.WinbindContainerName},
+	}
+	for _, n := range containerNames {
+		if errs := validation.IsDNS1123Label(n.value); len(errs) > 0 {
+			invalid(n.name, n.value, strings.Join(errs, ", "))
+		}
+	}
+	images := []struct {
+		name, value string
+		required    bool
+	}{
+		{"SmbdContainerImage", oc.SmbdContainerImage, true},
+		{"SvcWatchContainerImage", oc.SvcWatchContainerImage, false},
+		{"WinbindContainerImage", oc.WinbindContainerImage, false},
+		{"CTDBContainerImage", oc.CTDBContainerImage, false},
+		{"DNSRegisterContainerImage", oc.DNSRegisterContainerImage, false},
+		{"InitContainerImage", oc.InitContainerImage, false},
+		{"MetricsContainerImage", oc.MetricsContainerImage, false},
+	}
+	for _, img := range images {
+		if img.value == "" && !img.required {
+			continue
+		}
+		if !imageRefPattern.MatchString(img.value) {
+			invalid(img.name, img.value, "not a container image reference")
+		}
+	}
+	switch oc.ImagePullPolicy {
+	case "", corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever:
+	default:
+		invalid("ImagePullPolicy", oc.ImagePullPolicy, "")
+	}
+	for _, name := range oc.ImagePullSecrets {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			invalid("ImagePullSecrets", name, strings.Join(errs, ", "))
+		}
+	}
+	if oc.SambaDebugLevel != "" &&
+		!debugLevelPattern.MatchString(oc.SambaDebugLevel) {
+		invalid("SambaDebugLevel", oc.SambaDebugLevel,
+			`expected a level and debug classes, like "1 auth:5"`)
+	}
+	if oc.SambaContainerConfigVersion != "" &&
+		!smbcc.IsKnownVersion(oc.SambaContainerConfigVersion) {
+		invalid("SambaContainerConfigVersion",
+			oc.SambaContainerConfigVersion, "unknown version")
+	}
+	if oc.StatePVCSize.Sign() <= 0 {
+		invalid("StatePVCSize", oc.StatePVCSize.String(),
+			"must be greater than zero")
+	}
+	if oc.StatePVCStorageClass != "" {
+		errs := validation.IsDNS1123Subdomain(oc.StatePVCStorageClass)
+		if len(errs) > 0 {
+			invalid("StatePVCStorageClass", oc.StatePVCStorageClass,
+				strings.Join(errs, ", "))
+		}
+	}
+	for _, mode := range oc.StatePVCAccessModes {
+		switch mode {
+		case corev1.ReadWriteOnce, corev1.ReadOnlyMany, corev1.ReadWriteMany,
+			corev1.ReadWriteOncePod:
+		default:
+			invalid("StatePVCAccessModes", mode, "")
+		}
+	}
+	switch oc.StatePVCVolumeMode {
+	case "", corev1.PersistentVolumeFilesystem:
+	case corev1.PersistentVolumeBlock:
+		invalid("StatePVCVolumeMode", oc.StatePVCVolumeMode,
+			"the state PVC is mounted as a filesystem")
+	default:
+		invalid("StatePVCVolumeMode", oc.StatePVCVolumeMode, "")
+	}
+	switch oc.ClusterSupport {
+	case ClusterSupportDisabled, ClusterSupportCTDBExperimental:
+	default:
+		invalid("ClusterSupport", oc.ClusterSupport,
+			fmt.Sprintf("expected %q or unset", ClusterSupportCTDBExperimental))
+	}
+	switch oc.MetricsExporterMode {
+	case "", MetricsExporterEnabled, MetricsExporterDisabled:
+	default:
+		invalid("MetricsExporterMode", oc.MetricsExporterMode,
+			fmt.Sprintf("expected %q or %q",
+				MetricsExporterEnabled, MetricsExporterDisabled))
+	}
+	resourceLists := []struct {
+		name  string
+		value corev1.ResourceList
+	}{
+		{"smbd-resource-requests", oc.SmbdResourceRequests},
+		{"smbd-resource-limits", oc.SmbdResourceLimits},
+		{"winbind-resource-requests", oc.WinbindResourceRequests},
+		{"winbind-resource-limits", oc.WinbindResourceLimits},
+		{"ctdb-resource-requests", oc.CTDBResourceRequests},
+		{"ctdb-resource-limits", oc.CTDBResourceLimits},
+		{"dns-register-resource-requests", oc.DNSRegisterResourceRequests},
+		{"dns-register-resource-limits", oc.DNSRegisterResourceLimits},
+		{"svc-watch-resource-requests", oc.SvcWatchResourceRequests},
+		{"svc-watch-resource-limits", oc.SvcWatchResourceLimits},
+		{"init-resource-requests", oc.InitResourceRequests},
+		{"init-resource-limits", oc.InitResourceLimits},
+		{"metrics-resource-requests", oc.MetricsResourceRequests},
+		{"metrics-resource-limits", oc.MetricsResourceLimits},
+	}
+	for _, rl := range resourceLists {
+		for name, q := range rl.value {
+			if q.Sign() < 0 {
+				invalid(rl.name, fmt.Sprintf("%s=%s", name, q.String()),
+					"must not be negative")
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
 	}
 	return nil
 }
 
+// ParseResourceList converts a string of comma separated name=quantity
+// pairs into a ResourceList. An empty string results in a nil list.
+func ParseResourceList(s string) (corev1.ResourceList, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	rl := corev1.ResourceList{}
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected name=quantity, got %q", item)
+		}
+		name := strings.TrimSpace(parts[0])
+		if name == "" {
+			return nil, fmt.Errorf("missing resource name in %q", item)
+		}
+		q, err := resource.ParseQuantity(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %w", name, err)
+		}
+		rl[corev1.ResourceName(name)] = q
+	}
+	return rl, nil
+}
+
 // Source is how external configuration sources populate the operator config.
 type Source struct {
 	v    *viper.Viper
 	fset *pflag.FlagSet
+
+	lock      sync.Mutex
+	overrides map[string]string
 }
 
 // NewSource creates a new Source based on default configuration values.
@@ -65,12 +404,43 @@ func NewSource() *Source {
 	v.SetDefault("smbd-container-name", "samba")
 	v.SetDefault("winbind-container-name", "wb")
 	v.SetDefault("working-namespace", "")
+	v.SetDefault("watch-namespaces", "")
+	v.SetDefault("watch-namespace-selector", "")
+	v.SetDefault("server-namespace-mode", "share-namespace")
 	v.SetDefault(
 		"svc-watch-container-image",
 		"quay.io/samba.org/svcwatch:latest")
+	v.SetDefault("winbind-container-image", "")
+	v.SetDefault("ctdb-container-image", "")
+	v.SetDefault("dns-register-container-image", "")
+	v.SetDefault("init-container-image", "")
+	v.SetDefault("image-pull-policy", "")
+	v.SetDefault("image-pull-secrets", "")
 	v.SetDefault("samba-debug-level", "")
 	v.SetDefault("state-pvc-size", "1Gi")
+	v.SetDefault("state-pvc-storage-class", "")
+	v.SetDefault("state-pvc-access-modes", "ReadWriteMany")
+	v.SetDefault("state-pvc-volume-mode", "")
 	v.SetDefault("cluster-support", "")
+	v.SetDefault("smbd-resource-requests", "")
+	v.SetDefault("smbd-resource-limits", "")
+	v.SetDefault("winbind-resource-requests", "")
+	v.SetDefault("winbind-resource-limits", "")
+	v.SetDefault("ctdb-resource-requests", "")
+	v.SetDefault("ctdb-resource-limits", "")
+	v.SetDefault("dns-register-resource-requests", "")
+	v.SetDefault("dns-register-resource-limits", "")
+	v.SetDefault("svc-watch-resource-requests", "")
+	v.SetDefault("svc-watch-resource-limits", "")
+	v.SetDefault("init-resource-requests", "")
+	v.SetDefault("init-resource-limits", "")
+	v.SetDefault("metrics-resource-requests", "")
+	v.SetDefault("metrics-resource-limits", "")
+	v.SetDefault("metrics-exporter-mode", "disabled")
+	v.SetDefault(
+		"metrics-container-image",
+		"quay.io/samba.org/samba-metrics:latest")
+	v.SetDefault("samba-container-config-version", "v0")
 	return &Source{v: v}
 }
 
@@ -90,14 +460,63 @@ func (s *Source) Flags() *pflag.FlagSet {
 	return s.fset
 }
 
-// Read a new OperatorConfig from all available sources.
+// SetOverrides sets configuration values that take precedence over the
+// configuration file and the environment, but not over flags given on the
+// CLI. This is used for values read from a ConfigMap. Keys may be given as
+// configuration parameters, like "samba-debug-level", or as environment
+// variables, like "SAMBA_OP_SAMBA_DEBUG_LEVEL". The overrides replace any
+// previously set.
+func (s *Source) SetOverrides(data map[string]string) error {
+	known := map[string]bool{}
+	for _, k := range s.v.AllKeys() {
+		known[k] = true
+	}
+	overrides := map[string]string{}
+	for k, value := range data {
+		key := configKey(k)
+		if !known[key] {
+			return fmt.Errorf("unknown configuration parameter %q", k)
+		}
+		overrides[key] = value
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.overrides = overrides
+	return nil
+}
+
+// configKey converts the name of an environment variable to the name of
+// the configuration parameter it sets.
+func configKey(k string) string {
+	k = strings.TrimPrefix(k, "SAMBA_OP_")
+	return strings.ReplaceAll(strings.ToLower(k), "_", "-")
+}
+
+// configDirs are the directories searched for the configuration file.
+var configDirs = []string{"/etc/samba-operator", "."}
+
+// ConfigDirs returns the directories searched for the configuration file.
+// A change to a file in these directories may change the configuration.
+func (*Source) ConfigDirs() []string {
+	return append([]string{}, configDirs...)
+}
+
+// Read a new OperatorConfig from all available sources. The sources are
+// read again on every call, so that Read may be used to reload the
+// configuration.
 func (s *Source) Read() (*OperatorConfig, error) {
-	v := s.v
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	v := viper.New()
+	for _, k := range s.v.AllKeys() {
+		v.SetDefault(k, s.v.Get(k))
+	}
 
 	// we look in /etc/samba-operator and the working dir for
 	// yaml/toml/etc config files (none are required)
-	v.AddConfigPath("/etc/samba-operator")
-	v.AddConfigPath(".")
+	for _, dir := range configDirs {
+		v.AddConfigPath(dir)
+	}
 	v.SetConfigName("samba-operator")
 	err := v.ReadInConfig()
 	if err != nil {
@@ -115,12 +534,52 @@ func (s *Source) Read() (*OperatorConfig, error) {
 	if s.fset != nil {
 		v.BindPFlags(s.fset)
 	}
+	for k, value := range s.overrides {
+		if s.fset != nil && s.fset.Changed(k) {
+			continue
+		}
+		v.Set(k, value)
+	}
 
 	// we isolate config handling to this package. thus we marshal
 	// our config to the public OperatorConfig type and return that.
 	c := &OperatorConfig{}
-	if err := v.Unmarshal(c); err != nil {
+	err = v.Unmarshal(c, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		stringToQuantityHook,
+		stringToResourceListHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)))
+	var derr *mapstructure.Error
+	if errors.As(err, &derr) {
+		// values of the wrong type are reported by Validate along with
+		// the other problems of the configuration
+		c.decodeErrors = derr.Errors
+	} else if err != nil {
 		return nil, err
 	}
 	return c, nil
 }
+
+// stringToQuantityHook converts strings to resource quantities.
+func stringToQuantityHook(
+	from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	// ---
+	if from.Kind() != reflect.String ||
+		to != reflect.TypeOf(resource.Quantity{}) {
+		return data, nil
+	}
+	return resource.ParseQuantity(data.(string))
+}
+
+// stringToResourceListHook converts strings of comma separated
+// name=quantity pairs to resource lists.
+func stringToResourceListHook(
+	from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	// ---
+	if from.Kind() != reflect.String ||
+		to != reflect.TypeOf(corev1.ResourceList{}) {
+		return data, nil
+	}
+	return ParseResourceList(data.(string))
+}
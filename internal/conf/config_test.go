@@ -0,0 +1,266 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseResourceList(t *testing.T) {
+	rl, err := ParseResourceList("")
+	assert.NoError(t, err)
+	assert.Nil(t, rl)
+
+	rl, err = ParseResourceList("cpu=100m, memory=128Mi")
+	require.NoError(t, err)
+	assert.Len(t, rl, 2)
+	assert.True(t, rl[corev1.ResourceCPU].Equal(resource.MustParse("100m")))
+	assert.True(t, rl[corev1.ResourceMemory].Equal(resource.MustParse("128Mi")))
+
+	_, err = ParseResourceList("cpu")
+	assert.Error(t, err)
+
+	_, err = ParseResourceList("=100m")
+	assert.Error(t, err)
+
+	_, err = ParseResourceList("memory=lots")
+	assert.Error(t, err)
+}
+
+// validConfig returns a configuration with the values that are required.
+func validConfig() *OperatorConfig {
+	return &OperatorConfig{
+		WorkingNamespace:     "samba-operator-system",
+		SmbdContainerImage:   "quay.io/samba.org/samba-server:latest",
+		SmbdContainerName:    "samba",
+		WinbindContainerName: "wb",
+		StatePVCSize:         resource.MustParse("1Gi"),
+	}
+}
+
+func TestValidateResources(t *testing.T) {
+	oc := validConfig()
+	oc.SmbdResourceRequests = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("250m"),
+		corev1.ResourceMemory: resource.MustParse("256Mi"),
+	}
+	oc.SmbdResourceLimits = corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	assert.NoError(t, oc.Validate())
+
+	oc.CTDBResourceLimits = corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("-1Gi"),
+	}
+	assert.Error(t, oc.Validate())
+}
+
+func TestValidateImagePullPolicy(t *testing.T) {
+	oc := validConfig()
+	oc.ImagePullPolicy = "Always"
+	assert.NoError(t, oc.Validate())
+
+	oc.ImagePullPolicy = "Sometimes"
+	assert.Error(t, oc.Validate())
+}
+
+func TestValidate(t *testing.T) {
+	oc := validConfig()
+	oc.SambaDebugLevel = "1 auth:5"
+	oc.ClusterSupport = ClusterSupportCTDBExperimental
+	oc.MetricsExporterMode = MetricsExporterEnabled
+	oc.ImagePullSecrets = []string{"regcred"}
+	oc.CTDBContainerImage = "registry.example.com:5000/samba/ctdb:v1.0"
+	oc.InitContainerImage = "samba-server@sha256:" +
+		"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	assert.NoError(t, oc.Validate())
+
+	oc = validConfig()
+	oc.WorkingNamespace = ""
+	oc.SambaDebugLevel = "loud"
+	oc.ClusterSupport = "ctdb"
+	oc.MetricsExporterMode = "on"
+	oc.SmbdContainerImage = "Quay.io/Samba Server"
+	oc.ImagePullSecrets = []string{"Reg_Cred"}
+	oc.StatePVCSize = resource.MustParse("-1Gi")
+	oc.WinbindContainerName = "WB"
+	oc.StatePVCAccessModes = []corev1.PersistentVolumeAccessMode{"Shared"}
+	oc.WatchNamespaces = []string{"tenant_a"}
+	oc.WatchNamespaceSelector = "tenant in (a"
+	oc.ServerNamespaceMode = "central"
+	oc.StatePVCVolumeMode = corev1.PersistentVolumeBlock
+	err := oc.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 14)
+	for _, name := range []string{
+		"WorkingNamespace",
+		"SambaDebugLevel",
+		"ClusterSupport",
+		"MetricsExporterMode",
+		"SmbdContainerImage",
+		"ImagePullSecrets",
+		"StatePVCSize",
+		"WinbindContainerName",
+		"StatePVCAccessModes",
+		"WatchNamespaces",
+		"WatchNamespaceSelector",
+		"ServerNamespaceMode",
+		"StatePVCVolumeMode",
+	} {
+		assert.Contains(t, err.Error(), name)
+	}
+}
+
+func TestValidateNamespaces(t *testing.T) {
+	oc := validConfig()
+	oc.WatchNamespaces = []string{"tenant-a", "tenant-b"}
+	oc.ServerNamespaceMode = ServerNamespaceWorking
+	assert.NoError(t, oc.Validate())
+
+	oc = validConfig()
+	oc.WatchNamespaceSelector = "samba-operator.samba.org/tenant=true"
+	assert.NoError(t, oc.Validate())
+
+	oc.WatchNamespaces = []string{"tenant-a"}
+	err := oc.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can not be combined")
+}
+
+func TestSourceTypedValues(t *testing.T) {
+	s := NewSource()
+	require.NoError(t, s.SetOverrides(map[string]string{
+		"working-namespace":               "samba-operator-system",
+		"image-pull-secrets":              "regcred,other",
+		"state-pvc-size":                  "2Gi",
+		"cluster-support":                 "ctdb-is-experimental",
+		"SAMBA_OP_STATE_PVC_ACCESS_MODES": "ReadWriteMany,ReadOnlyMany",
+		"smbd-resource-requests":          "cpu=100m, memory=128Mi",
+	}))
+	c, err := s.Read()
+	require.NoError(t, err)
+	assert.NoError(t, c.Validate())
+	assert.Equal(t, []string{"regcred", "other"}, c.ImagePullSecrets)
+	assert.True(t, c.StatePVCSize.Equal(resource.MustParse("2Gi")))
+	assert.Equal(t, ClusterSupportCTDBExperimental, c.ClusterSupport)
+	assert.Equal(t, MetricsExporterDisabled, c.MetricsExporterMode)
+	assert.Equal(t,
+		[]corev1.PersistentVolumeAccessMode{
+			corev1.ReadWriteMany, corev1.ReadOnlyMany,
+		},
+		c.StatePVCAccessModes)
+	assert.Len(t, c.SmbdResourceRequests, 2)
+	assert.True(t, c.SmbdResourceRequests.Memory().Equal(
+		resource.MustParse("128Mi")))
+	assert.Nil(t, c.SmbdResourceLimits)
+
+	// values that can not be converted are reported with other problems
+	require.NoError(t, s.SetOverrides(map[string]string{
+		"state-pvc-size":       "big",
+		"samba-debug-level":    "loud",
+		"ctdb-resource-limits": "memory:1Gi",
+	}))
+	c, err = s.Read()
+	require.NoError(t, err)
+	err = c.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "state-pvc-size")
+	assert.Contains(t, err.Error(), "ctdb-resource-limits")
+	assert.Contains(t, err.Error(), "SambaDebugLevel")
+	assert.Contains(t, err.Error(), "WorkingNamespace")
+}
+
+func TestSourceOverrides(t *testing.T) {
+	s := NewSource()
+	err := s.SetOverrides(map[string]string{
+		"samba-debug-level":             "3",
+		"SAMBA_OP_SMBD_CONTAINER_IMAGE": "example.com/samba:test",
+	})
+	require.NoError(t, err)
+	c, err := s.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "3", c.SambaDebugLevel)
+	assert.Equal(t, "example.com/samba:test", c.SmbdContainerImage)
+	assert.Equal(t, "samba", c.SmbdContainerName)
+
+	// flags given on the CLI win over overrides
+	require.NoError(t, s.Flags().Parse([]string{"--samba-debug-level=5"}))
+	c, err = s.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "5", c.SambaDebugLevel)
+
+	// overrides are replaced, not merged
+	require.NoError(t, s.SetOverrides(nil))
+	c, err = s.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "quay.io/samba.org/samba-server:latest", c.SmbdContainerImage)
+
+	err = s.SetOverrides(map[string]string{"no-such-thing": "1"})
+	assert.Error(t, err)
+}
+
+func TestReload(t *testing.T) {
+	defer Set(Get())
+	s := NewSource()
+	require.NoError(t, s.SetOverrides(map[string]string{
+		"working-namespace": "samba-operator-system",
+	}))
+	require.NoError(t, Load(s))
+	first := Get()
+
+	changed, err := Reload(s)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Same(t, first, Get())
+
+	require.NoError(t, s.SetOverrides(map[string]string{
+		"working-namespace": "samba-operator-system",
+		"samba-debug-level": "4",
+	}))
+	changed, err = Reload(s)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "4", Get().SambaDebugLevel)
+	assert.Equal(t, "", first.SambaDebugLevel)
+
+	// invalid configurations are rejected
+	require.NoError(t, s.SetOverrides(map[string]string{
+		"working-namespace": "samba-operator-system",
+		"image-pull-policy": "Sometimes",
+	}))
+	changed, err = Reload(s)
+	assert.Error(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "4", Get().SambaDebugLevel)
+
+	// namespace settings are only applied at startup
+	require.NoError(t, s.SetOverrides(map[string]string{
+		"working-namespace":     "samba-operator-system",
+		"server-namespace-mode": "working-namespace",
+	}))
+	changed, err = Reload(s)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ServerNamespaceMode")
+	assert.False(t, changed)
+	assert.Equal(t, ServerNamespaceShare, Get().ServerNamespaceMode)
+}
@@ -1,18 +1,95 @@
 package conf
 
-var globalConf *OperatorConfig
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
 
-// Get the global operator configuration object.
+var (
+	globalLock sync.RWMutex
+	globalConf *OperatorConfig
+
+	// reloadLock serializes reloads so that an older configuration never
+	// replaces a newer one.
+	reloadLock sync.Mutex
+)
+
+// Get the global operator configuration object. The returned object is
+// never modified; a reload replaces it with a new object.
 func Get() *OperatorConfig {
+	globalLock.RLock()
+	defer globalLock.RUnlock()
 	return globalConf
 }
 
+// Set the global operator configuration object.
+func Set(c *OperatorConfig) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	globalConf = c
+}
+
 // Load the global operator configuration.
 func Load(s *Source) error {
 	c, err := s.Read()
 	if err != nil {
 		return err
 	}
-	globalConf = c
+	Set(c)
+	return nil
+}
+
+// Reload reads the configuration from the source again and, if it is
+// valid and differs from the global configuration, replaces the global
+// configuration. It returns true if the configuration was replaced. An
+// invalid configuration, or one changing values that are only applied at
+// startup, is rejected and the current one is kept.
+func Reload(s *Source) (bool, error) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+	c, err := s.Read()
+	if err != nil {
+		return false, err
+	}
+	if err := c.Validate(); err != nil {
+		return false, err
+	}
+	if err := checkStartupValues(Get(), c); err != nil {
+		return false, err
+	}
+	if reflect.DeepEqual(c, Get()) {
+		return false, nil
+	}
+	Set(c)
+	return true, nil
+}
+
+// checkStartupValues returns an error if the new configuration changes a
+// value that is only applied when the operator starts.
+func checkStartupValues(old, c *OperatorConfig) error {
+	if old == nil {
+		return nil
+	}
+	changed := func(name string, a, b interface{}) error {
+		if reflect.DeepEqual(a, b) {
+			return nil
+		}
+		return fmt.Errorf(
+			"%s can not be changed without restarting the operator", name)
+	}
+	checks := []error{
+		changed("WorkingNamespace", old.WorkingNamespace, c.WorkingNamespace),
+		changed("WatchNamespaces", old.WatchNamespaces, c.WatchNamespaces),
+		changed("WatchNamespaceSelector",
+			old.WatchNamespaceSelector, c.WatchNamespaceSelector),
+		changed("ServerNamespaceMode",
+			old.ServerNamespaceMode, c.ServerNamespaceMode),
+	}
+	for _, err := range checks {
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
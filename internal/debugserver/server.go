@@ -0,0 +1,261 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package debugserver serves the operator's view of SmbShares over HTTP to
+// help troubleshooting.
+package debugserver
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/resources"
+)
+
+// SharePathPrefix is the path under which the debug information of a
+// share is served, followed by the namespace and name of the share.
+const SharePathPrefix = "/debug/smbshares/"
+
+// debugSubresource is the subresource of smbshares a user must be allowed
+// to get to access the debug information of a share.
+const debugSubresource = "debug"
+
+// The names of the serving certificate and key in the certificate
+// directory, matching those of the webhook server.
+const (
+	certName = "tls.crt"
+	keyName  = "tls.key"
+)
+
+var (
+	errUnauthenticated = errors.New("unauthenticated")
+	errForbidden       = errors.New("forbidden")
+	errNoCertDir       = errors.New(
+		"the debug endpoint authenticates with bearer tokens " +
+			"and requires a certificate directory to serve TLS")
+)
+
+//revive:disable kubebuilder directives
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+//revive:enable
+
+// authorizeFunc checks that the bearer token grants access to the debug
+// information of the share.
+type authorizeFunc func(
+	ctx context.Context, token string, nsname types.NamespacedName) error
+
+// Server serves the debug information of SmbShares over TLS. Requests are
+// authenticated with a bearer token, and the user must be allowed to get
+// the "debug" subresource of the share.
+type Server struct {
+	Addr string
+	// CertDir is the directory holding the tls.crt and tls.key files of
+	// the serving certificate. Changes to the files are picked up without
+	// a restart. The server refuses to start without it, as bearer tokens
+	// must not be sent over plain HTTP.
+	CertDir string
+	Client  client.Client
+	Scheme  *runtime.Scheme
+	Log     logr.Logger
+
+	authorize authorizeFunc
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. The debug
+// information is available from every instance of the operator.
+func (*Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves requests until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	if s.CertDir == "" {
+		return errNoCertDir
+	}
+	watcher, err := certwatcher.New(
+		filepath.Join(s.CertDir, certName),
+		filepath.Join(s.CertDir, keyName))
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := watcher.Start(ctx); err != nil {
+			s.Log.Error(err, "Failed to watch the serving certificate")
+		}
+	}()
+	srv := &http.Server{
+		Addr:    s.Addr,
+		Handler: s,
+		TLSConfig: &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: watcher.GetCertificate,
+		},
+	}
+	errc := make(chan error, 1)
+	go func() {
+		s.Log.Info("Serving debug information",
+			"address", s.Addr, "certDir", s.CertDir)
+		errc <- srv.ListenAndServeTLS("", "")
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(sctx)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.TLS == nil {
+		// never accept a bearer token sent in the clear
+		http.Error(w, "TLS required", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	nsname, ok := parseSharePath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	token := bearerToken(r)
+	if token == "" {
+		http.Error(w, errUnauthenticated.Error(), http.StatusUnauthorized)
+		return
+	}
+	authorize := s.authorize
+	if authorize == nil {
+		authorize = s.reviewAccess
+	}
+	err := authorize(r.Context(), token, nsname)
+	switch {
+	case errors.Is(err, errUnauthenticated):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errors.Is(err, errForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case err != nil:
+		s.Log.Error(err, "Failed to review access", "path", r.URL.Path)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log := s.Log.WithValues("smbshare", nsname)
+	m := resources.NewSmbShareManager(s.Client, s.Scheme, nil, log)
+	info, err := m.DebugShare(r.Context(), nsname)
+	if kerrors.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Error(err, "Failed to get debug information")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(info); err != nil {
+		log.Error(err, "Failed to write debug information")
+	}
+}
+
+// reviewAccess authenticates the token with a TokenReview and checks the
+// access of the user with a SubjectAccessReview.
+func (s *Server) reviewAccess(
+	ctx context.Context, token string, nsname types.NamespacedName) error {
+	// ---
+	tr := &authnv1.TokenReview{
+		Spec: authnv1.TokenReviewSpec{Token: token},
+	}
+	if err := s.Client.Create(ctx, tr); err != nil {
+		return err
+	}
+	if !tr.Status.Authenticated {
+		return errUnauthenticated
+	}
+	user := tr.Status.User
+	extra := map[string]authzv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authzv1.ExtraValue(v)
+	}
+	sar := &authzv1.SubjectAccessReview{
+		Spec: authzv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authzv1.ResourceAttributes{
+				Namespace:   nsname.Namespace,
+				Name:        nsname.Name,
+				Verb:        "get",
+				Group:       sambaoperatorv1alpha1.GroupVersion.Group,
+				Resource:    "smbshares",
+				Subresource: debugSubresource,
+			},
+		},
+	}
+	if err := s.Client.Create(ctx, sar); err != nil {
+		return err
+	}
+	if !sar.Status.Allowed {
+		return errForbidden
+	}
+	return nil
+}
+
+func parseSharePath(p string) (types.NamespacedName, bool) {
+	if !strings.HasPrefix(p, SharePathPrefix) {
+		return types.NamespacedName{}, false
+	}
+	parts := strings.Split(strings.TrimPrefix(p, SharePathPrefix), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, true
+}
+
+func bearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(auth, prefix))
+}
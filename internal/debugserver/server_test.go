@@ -0,0 +1,179 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debugserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+)
+
+func TestParseSharePath(t *testing.T) {
+	nsname, ok := parseSharePath("/debug/smbshares/ns1/share1")
+	assert.True(t, ok)
+	assert.Equal(t,
+		types.NamespacedName{Namespace: "ns1", Name: "share1"}, nsname)
+
+	for _, p := range []string{
+		"/debug/smbshares/ns1",
+		"/debug/smbshares/ns1/",
+		"/debug/smbshares//share1",
+		"/debug/smbshares/ns1/share1/x",
+		"/metrics",
+	} {
+		_, ok := parseSharePath(p)
+		assert.False(t, ok, p)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	s := &Server{
+		Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Scheme: scheme,
+		Log:    logr.Discard(),
+		authorize: func(
+			_ context.Context, token string, _ types.NamespacedName) error {
+			// ---
+			switch token {
+			case "admin":
+				return nil
+			case "user":
+				return errForbidden
+			}
+			return errUnauthenticated
+		},
+	}
+	get := func(path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.TLS = &tls.ConnectionState{}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	path := SharePathPrefix + "ns1/share1"
+	assert.Equal(t, http.StatusUnauthorized, get(path, ""))
+	assert.Equal(t, http.StatusUnauthorized, get(path, "bogus"))
+	assert.Equal(t, http.StatusForbidden, get(path, "user"))
+	assert.Equal(t, http.StatusNotFound, get(path, "admin"))
+	assert.Equal(t, http.StatusNotFound, get("/debug/other", "admin"))
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 to dir.
+func writeTestCert(t *testing.T, dir string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "debug"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, certName),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		0600))
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, keyName),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		0600))
+}
+
+func TestStartTLS(t *testing.T) {
+	s := &Server{Addr: "127.0.0.1:0", Log: logr.Discard()}
+	assert.Equal(t, errNoCertDir, s.Start(context.Background()))
+
+	// no certificate in the directory
+	s.CertDir = t.TempDir()
+	assert.Error(t, s.Start(context.Background()))
+
+	writeTestCert(t, s.CertDir)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s.Addr = l.Addr().String()
+	require.NoError(t, l.Close())
+	s.authorize = func(context.Context, string, types.NamespacedName) error {
+		return errForbidden
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Start(ctx) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		// the test certificate is self-signed
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	url := "https://" + s.Addr + SharePathPrefix + "ns1/share1"
+	var resp *http.Response
+	assert.Eventually(t, func() bool {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer user")
+		resp, err = client.Do(req)
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	if resp != nil {
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	// plain HTTP requests are rejected by the TLS server
+	resp, err = http.Get("http://" + s.Addr + SharePathPrefix + "ns1/share1")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+	cancel()
+	assert.NoError(t, <-done)
+}
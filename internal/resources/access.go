@@ -0,0 +1,86 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+const validUsersParam = "valid users"
+
+// validUsers returns the value of the valid users share parameter for the
+// share's access list, or an empty string if access is not restricted.
+// Groups are prefixed with "@" and names containing spaces are quoted.
+func (sp *sharePlanner) validUsers() string {
+	if sp.SmbShare == nil || sp.SmbShare.Spec.Access == nil {
+		return ""
+	}
+	access := sp.SmbShare.Spec.Access
+	names := make([]string, 0, len(access.Users)+len(access.Groups))
+	for _, u := range access.Users {
+		names = append(names, quoteName(u))
+	}
+	for _, g := range access.Groups {
+		names = append(names, quoteName("@"+g))
+	}
+	return strings.Join(names, " ")
+}
+
+func quoteName(n string) string {
+	if strings.ContainsAny(n, " \t,") {
+		return `"` + n + `"`
+	}
+	return n
+}
+
+// updateAccessOptions brings the valid users share parameter in line with
+// the share's access list. It returns true if the options changed.
+func (sp *sharePlanner) updateAccessOptions(opts smbcc.SmbOptions) bool {
+	desired := sp.validUsers()
+	cur, have := opts[validUsersParam]
+	switch {
+	case desired != "" && (!have || cur != desired):
+		opts[validUsersParam] = desired
+		return true
+	case desired == "" && have:
+		delete(opts, validUsersParam)
+		return true
+	}
+	return false
+}
+
+// accessProblems returns the users and groups of the share's access list
+// that are not defined in the given users config.
+func (sp *sharePlanner) accessProblems(cc *smbcc.SambaContainerConfig) []string {
+	if sp.SmbShare == nil || sp.SmbShare.Spec.Access == nil {
+		return nil
+	}
+	var problems []string
+	for _, u := range sp.SmbShare.Spec.Access.Users {
+		if !cc.HasUser(u) {
+			problems = append(problems, fmt.Sprintf("unknown user %q", u))
+		}
+	}
+	for _, g := range sp.SmbShare.Spec.Access.Groups {
+		if !cc.HasGroup(g) {
+			problems = append(problems, fmt.Sprintf("unknown group %q", g))
+		}
+	}
+	return problems
+}
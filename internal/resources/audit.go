@@ -0,0 +1,236 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/samba-in-kubernetes/samba-operator/internal/auditlog"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+const (
+	vfsObjectsParam  = "vfs objects"
+	fullAuditModule  = "full_audit"
+	auditPrefixParam = "full_audit:prefix"
+)
+
+const (
+	auditLogContainerName = "audit-log"
+	// auditSocketDir holds the syslog socket of the audit-log container.
+	// smbd sends its syslog messages to /dev/log, which is linked to the
+	// socket before smbd starts.
+	auditSocketDir = "/run/samba-audit"
+	// sambaContainerEntrypoint is the entrypoint of the samba container
+	// images. The command of the smbd container of audited shares replaces
+	// the entrypoint and runs it itself.
+	sambaContainerEntrypoint = "samba-container"
+)
+
+// auditParams lists the vfs_full_audit share parameters managed by the
+// operator, apart from the vfs objects list.
+var auditParams = []string{
+	auditPrefixParam,
+	"full_audit:success",
+	"full_audit:failure",
+	"full_audit:syslog",
+	"full_audit:facility",
+	"full_audit:priority",
+}
+
+func (sp *sharePlanner) auditEnabled() bool {
+	return sp.SmbShare != nil && sp.SmbShare.Spec.Audit != nil
+}
+
+// auditOptions returns the share options that configure vfs_full_audit.
+// The records are sent to syslog, where the audit-log container receives
+// them and prints them as JSON objects.
+func (sp *sharePlanner) auditOptions() smbcc.SmbOptions {
+	if !sp.auditEnabled() {
+		return nil
+	}
+	audit := sp.SmbShare.Spec.Audit
+	ops := make([]string, 0, len(audit.Operations))
+	for _, op := range audit.Operations {
+		ops = append(ops, string(op))
+	}
+	success, failure := "none", "none"
+	if audit.Success {
+		success = strings.Join(ops, " ")
+	}
+	if audit.Failure {
+		failure = strings.Join(ops, " ")
+	}
+	return smbcc.SmbOptions{
+		auditPrefixParam:      auditlog.Prefix,
+		"full_audit:success":  success,
+		"full_audit:failure":  failure,
+		"full_audit:syslog":   smbcc.Yes,
+		"full_audit:facility": "LOCAL5",
+		"full_audit:priority": "NOTICE",
+	}
+}
+
+// updateAuditOptions brings the audit related share options in line with
+// the share's audit settings. It returns true if the options changed.
+func (sp *sharePlanner) updateAuditOptions(opts smbcc.SmbOptions) bool {
+	changed := false
+	desired := sp.auditOptions()
+	for _, k := range auditParams {
+		v, want := desired[k]
+		cur, have := opts[k]
+		switch {
+		case want && (!have || cur != v):
+			opts[k] = v
+			changed = true
+		case !want && have:
+			delete(opts, k)
+			changed = true
+		}
+	}
+	vfsObjects := updateVFSObjects(
+		opts[vfsObjectsParam], fullAuditModule, desired != nil)
+	if vfsObjects != opts[vfsObjectsParam] {
+		if vfsObjects == "" {
+			delete(opts, vfsObjectsParam)
+		} else {
+			opts[vfsObjectsParam] = vfsObjects
+		}
+		changed = true
+	}
+	return changed
+}
+
+// updateVFSObjects adds or removes module from a vfs objects list, keeping
+// any other modules in place.
+func updateVFSObjects(current, module string, enable bool) string {
+	var mods []string
+	found := false
+	for _, m := range strings.Fields(current) {
+		if m == module {
+			found = true
+			if !enable {
+				continue
+			}
+		}
+		mods = append(mods, m)
+	}
+	if enable && !found {
+		mods = append(mods, module)
+	}
+	if !enable && !found || enable && found {
+		// nothing to change, keep the original formatting
+		return current
+	}
+	return strings.Join(mods, " ")
+}
+
+// auditSocketPath returns the path of the syslog socket of the audit-log
+// container.
+func auditSocketPath() string {
+	return path.Join(auditSocketDir, "log")
+}
+
+// applyAuditLog adds the audit-log container to the pod spec of an audited
+// share. The container receives the audit records of smbd through a
+// socket on a volume shared with the smbd container, and smbd is started
+// with /dev/log pointing to that socket.
+func applyAuditLog(planner *sharePlanner, podSpec *corev1.PodSpec) {
+	if !planner.auditEnabled() {
+		return
+	}
+	vol := auditVolumeAndMount(planner)
+	podSpec.Volumes = append(podSpec.Volumes, vol.volume)
+	for i := range podSpec.Containers {
+		ctr := &podSpec.Containers[i]
+		if ctr.Name != planner.GlobalConfig.SmbdContainerName {
+			continue
+		}
+		ctr.VolumeMounts = append(ctr.VolumeMounts, vol.mount)
+		ctr.Command = auditSmbdCommand()
+	}
+	podSpec.Containers = append(
+		podSpec.Containers, buildAuditLogCtr(planner, []volMount{vol}))
+}
+
+// auditSmbdCommand returns the command of the smbd container of an audited
+// share. It links /dev/log to the socket of the audit-log container and
+// runs the image's entrypoint with the arguments of the container. Images
+// whose entrypoint is not samba-container can not be audited.
+func auditSmbdCommand() []string {
+	script := fmt.Sprintf(
+		"ln -sf %s /dev/log && exec %s \"$@\"",
+		shellQuote(auditSocketPath()), sambaContainerEntrypoint)
+	return []string{"/bin/sh", "-c", script, sambaContainerEntrypoint}
+}
+
+// syncAuditLog adds or removes the audit-log container of the pod template
+// when auditing is enabled or disabled. It returns true if the template
+// was changed.
+func syncAuditLog(
+	planner *sharePlanner,
+	tmpl *corev1.PodTemplateSpec) bool {
+	// ---
+	found := false
+	for _, ctr := range tmpl.Spec.Containers {
+		if ctr.Name == auditLogContainerName {
+			found = true
+		}
+	}
+	switch {
+	case planner.auditEnabled() && !found:
+		applyAuditLog(planner, &tmpl.Spec)
+		return true
+	case !planner.auditEnabled() && found:
+		removeAuditLog(planner, &tmpl.Spec)
+		return true
+	}
+	return false
+}
+
+// removeAuditLog undoes applyAuditLog.
+func removeAuditLog(planner *sharePlanner, podSpec *corev1.PodSpec) {
+	volName := auditVolumeAndMount(planner).volume.Name
+	vols := podSpec.Volumes[:0]
+	for _, v := range podSpec.Volumes {
+		if v.Name != volName {
+			vols = append(vols, v)
+		}
+	}
+	podSpec.Volumes = vols
+	ctrs := podSpec.Containers[:0]
+	for _, ctr := range podSpec.Containers {
+		if ctr.Name == auditLogContainerName {
+			continue
+		}
+		if ctr.Name == planner.GlobalConfig.SmbdContainerName {
+			ctr.Command = nil
+			mounts := []corev1.VolumeMount{}
+			for _, m := range ctr.VolumeMounts {
+				if m.Name != volName {
+					mounts = append(mounts, m)
+				}
+			}
+			ctr.VolumeMounts = mounts
+		}
+		ctrs = append(ctrs, ctr)
+	}
+	podSpec.Containers = ctrs
+}
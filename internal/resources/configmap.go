@@ -50,23 +50,25 @@ func newDefaultConfigMap(name, ns string) (*corev1.ConfigMap, error) {
 	return cm, nil
 }
 
+// getContainerConfig returns the container config stored in the ConfigMap,
+// migrated to the current schema version, and the version it was stored
+// in.
 func getContainerConfig(
-	cm *corev1.ConfigMap) (*smbcc.SambaContainerConfig, error) {
+	cm *corev1.ConfigMap) (*smbcc.SambaContainerConfig, string, error) {
 	// ---
-	cc := smbcc.New()
 	jstr, found := cm.Data[ConfigJSONKey]
 	if !found {
-		return cc, nil
+		return smbcc.New(), smbcc.CurrentVersion, nil
 	}
-	if err := json.Unmarshal([]byte(jstr), cc); err != nil {
-		return nil, err
-	}
-	return cc, nil
+	return smbcc.Parse([]byte(jstr))
 }
 
 func setContainerConfig(
 	cm *corev1.ConfigMap, cc *smbcc.SambaContainerConfig) error {
 	// ---
+	if err := cc.Validate(); err != nil {
+		return err
+	}
 	jb, err := json.MarshalIndent(cc, "", "  ")
 	if err != nil {
 		return err
@@ -0,0 +1,91 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+func TestUpdateConfiguration(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ctest", Name: "s1"},
+		Spec:       sambaoperatorv1alpha1.SmbShareSpec{ShareName: "Stuff"},
+		Status:     sambaoperatorv1alpha1.SmbShareStatus{ServerGroup: "s1"},
+	}
+	cm, err := newDefaultConfigMap("s1", "ctest")
+	require.NoError(t, err)
+	m := &SmbShareManager{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(share, cm).
+			Build(),
+		scheme: scheme,
+		logger: logr.Discard(),
+		cfg:    &conf.OperatorConfig{},
+	}
+	ctx := context.TODO()
+	key := rtclient.ObjectKeyFromObject(cm)
+
+	stale := &corev1.ConfigMap{}
+	require.NoError(t, m.client.Get(ctx, key, stale))
+
+	// another writer adds a share to the config
+	other := &corev1.ConfigMap{}
+	require.NoError(t, m.client.Get(ctx, key, other))
+	cc, _, err := getContainerConfig(other)
+	require.NoError(t, err)
+	cc.Shares["other"] = smbcc.NewSimpleShare("/other")
+	require.NoError(t, setContainerConfig(other, cc))
+	require.NoError(t, m.client.Update(ctx, other))
+
+	planner, changes, err := m.updateConfiguration(ctx, stale, share)
+	require.NoError(t, err)
+	assert.Contains(t, changes, smbcc.Change{
+		Kind:    smbcc.Added,
+		Section: smbcc.SharesSection,
+		Key:     "Stuff",
+	})
+	assert.Contains(t, planner.ConfigState.Shares, smbcc.Key("other"))
+
+	stored := &corev1.ConfigMap{}
+	require.NoError(t, m.client.Get(ctx, key, stored))
+	cc, _, err = getContainerConfig(stored)
+	require.NoError(t, err)
+	assert.Contains(t, cc.Shares, smbcc.Key("Stuff"))
+	assert.Contains(t, cc.Shares, smbcc.Key("other"))
+
+	_, changes, err = m.updateConfiguration(ctx, stored, share)
+	require.NoError(t, err)
+	assert.Len(t, changes, 0)
+}
@@ -0,0 +1,248 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+// ShareDebugInfo describes how the operator configures a share.
+type ShareDebugInfo struct {
+	// Instance is the configuration resolved for the share.
+	Instance InstanceConfiguration `json:"instanceConfiguration"`
+	// ContainerConfig is the samba container config computed for the
+	// share's server group.
+	ContainerConfig *smbcc.SambaContainerConfig `json:"containerConfig"`
+	// ConfigDiff is the difference between the container config stored in
+	// the server group's ConfigMap and ContainerConfig.
+	ConfigDiff string `json:"configDiff,omitempty"`
+	// Objects are the resources the operator manages for the share.
+	Objects []DebugObject `json:"objects"`
+}
+
+// DebugObject is a resource the operator manages for a share.
+type DebugObject struct {
+	Kind    string          `json:"kind"`
+	Name    string          `json:"name"`
+	Desired rtclient.Object `json:"desired"`
+	Exists  bool            `json:"exists"`
+	// Diff is the difference between the existing resource and the desired
+	// one. Only fields set in the desired resource are compared, so that
+	// defaults filled in by the API server are not reported.
+	Diff string `json:"diff,omitempty"`
+}
+
+// debugTarget pairs a desired resource with an empty object of the same
+// type to fetch the existing resource into.
+type debugTarget struct {
+	kind    string
+	desired rtclient.Object
+	current rtclient.Object
+}
+
+// DebugShare returns how the operator would configure the share with the
+// given name, compared to the resources that currently exist. Nothing is
+// changed in the cluster.
+func (m *SmbShareManager) DebugShare(
+	ctx context.Context,
+	nsname types.NamespacedName) (*ShareDebugInfo, error) {
+	// ---
+	share := &sambaoperatorv1alpha1.SmbShare{}
+	if err := m.client.Get(ctx, nsname, share); err != nil {
+		return nil, err
+	}
+	ns := m.serverNamespace(share)
+
+	cc := smbcc.New()
+	cm, err := m.getConfigMap(ctx, share, ns)
+	if err == nil {
+		if err = m.checkOwner(share, cm); err != nil {
+			return nil, err
+		}
+		if cc, _, err = getContainerConfig(cm); err != nil {
+			return nil, err
+		}
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	current, err := toUnstructured(cc)
+	if err != nil {
+		return nil, err
+	}
+	planner, err := m.newPlanner(ctx, share, cc)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := planner.update(); err != nil {
+		return nil, err
+	}
+	claimName := ""
+	if share.Spec.Storage.Pvc != nil {
+		if shareNeedsPvc(share) && share.Spec.Storage.Pvc.Name == "" {
+			share.Spec.Storage.Pvc.Name = pvcName(planner)
+		}
+		claimName = share.Spec.Storage.Pvc.Name
+	}
+	desired, err := toUnstructured(planner.ConfigState)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := planner.InstanceConfiguration
+	instance.GlobalConfig = shareGlobalConfig(instance.GlobalConfig)
+	info := &ShareDebugInfo{
+		Instance:        instance,
+		ContainerConfig: planner.ConfigState,
+		ConfigDiff:      cmp.Diff(current, desired),
+	}
+	objects := plannedObjects(m.cfg, planner, claimName, ns)
+	for _, o := range objects {
+		dobj := DebugObject{
+			Kind:    o.kind,
+			Name:    o.desired.GetName(),
+			Desired: o.desired,
+		}
+		err := m.client.Get(ctx, rtclient.ObjectKeyFromObject(o.desired), o.current)
+		if err == nil {
+			dobj.Exists = true
+			dobj.Diff, err = diffObjects(o.desired, o.current)
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		info.Objects = append(info.Objects, dobj)
+	}
+	return info, nil
+}
+
+// shareGlobalConfig returns a copy of the operator configuration without
+// the values that describe the operator itself rather than how it hosts
+// shares: the namespace it works in and the namespaces it watches.
+func shareGlobalConfig(oc *conf.OperatorConfig) *conf.OperatorConfig {
+	if oc == nil {
+		return nil
+	}
+	redacted := *oc
+	redacted.WorkingNamespace = ""
+	redacted.WatchNamespaces = nil
+	redacted.WatchNamespaceSelector = ""
+	return &redacted
+}
+
+// plannedObjects returns the resources, other than ConfigMaps and PVCs,
+// that host the share planned by the planner.
+func plannedObjects(
+	cfg *conf.OperatorConfig,
+	planner *sharePlanner,
+	claimName, ns string) []debugTarget {
+	// ---
+	var objects []debugTarget
+	if planner.isClustered() {
+		objects = append(objects, debugTarget{"StatefulSet",
+			buildStatefulSet(
+				planner,
+				claimName,
+				sharedStatePVCName(planner),
+				ns),
+			&appsv1.StatefulSet{}})
+	} else {
+		objects = append(objects, debugTarget{"Deployment",
+			buildDeployment(cfg, planner, claimName, ns),
+			&appsv1.Deployment{}})
+	}
+	objects = append(objects,
+		debugTarget{"PodDisruptionBudget",
+			buildPodDisruptionBudget(planner, ns),
+			&policyv1.PodDisruptionBudget{}},
+		debugTarget{"Service",
+			newServiceForSmb(planner, ns),
+			&corev1.Service{}})
+	if planner.metricsEnabled() {
+		objects = append(objects, debugTarget{"Service",
+			newMetricsServiceForSmb(planner, ns),
+			&corev1.Service{}})
+	}
+	return objects
+}
+
+// diffObjects returns the difference between the spec of an existing
+// resource and the spec of the desired resource.
+func diffObjects(desired, current rtclient.Object) (string, error) {
+	d, err := toUnstructured(desired)
+	if err != nil {
+		return "", err
+	}
+	c, err := toUnstructured(current)
+	if err != nil {
+		return "", err
+	}
+	dspec := d.(map[string]interface{})["spec"]
+	cspec := c.(map[string]interface{})["spec"]
+	return cmp.Diff(pruneTo(dspec, cspec), dspec), nil
+}
+
+func toUnstructured(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var u interface{}
+	err = json.Unmarshal(b, &u)
+	return u, err
+}
+
+// pruneTo removes the fields of current that are not set in desired.
+// Lists are pruned element by element when they are of the same length.
+func pruneTo(desired, current interface{}) interface{} {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		c, ok := current.(map[string]interface{})
+		if !ok {
+			return current
+		}
+		out := map[string]interface{}{}
+		for k, dv := range d {
+			if cv, found := c[k]; found {
+				out[k] = pruneTo(dv, cv)
+			}
+		}
+		return out
+	case []interface{}:
+		c, ok := current.([]interface{})
+		if !ok || len(c) != len(d) {
+			return current
+		}
+		out := make([]interface{}, len(c))
+		for i := range c {
+			out[i] = pruneTo(d[i], c[i])
+		}
+		return out
+	}
+	return current
+}
@@ -0,0 +1,121 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+)
+
+func TestDebugShare(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "dtest", Name: "s1"},
+		Spec: sambaoperatorv1alpha1.SmbShareSpec{
+			ShareName: "Stuff",
+			Storage: sambaoperatorv1alpha1.SmbShareStorageSpec{
+				Pvc: &sambaoperatorv1alpha1.SmbSharePvcSpec{Name: "data"},
+			},
+		},
+		Status: sambaoperatorv1alpha1.SmbShareStatus{ServerGroup: "s1"},
+	}
+	m := &SmbShareManager{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(share).
+			Build(),
+		scheme: scheme,
+		logger: logr.Discard(),
+		cfg: &conf.OperatorConfig{
+			SmbdContainerImage: "quay.io/samba.org/samba-server:latest",
+			SmbdContainerName:  "samba",
+			WorkingNamespace:   "samba-operator-system",
+			WatchNamespaces:    []string{"dtest"},
+		},
+	}
+	ctx := context.TODO()
+	nsname := types.NamespacedName{Namespace: "dtest", Name: "s1"}
+
+	info, err := m.DebugShare(ctx, nsname)
+	require.NoError(t, err)
+	assert.Equal(t, "s1", info.Instance.SmbShare.Name)
+	// only the configuration of shares is revealed
+	assert.Equal(t,
+		"quay.io/samba.org/samba-server:latest",
+		info.Instance.GlobalConfig.SmbdContainerImage)
+	assert.Empty(t, info.Instance.GlobalConfig.WorkingNamespace)
+	assert.Empty(t, info.Instance.GlobalConfig.WatchNamespaces)
+	assert.Equal(t, "samba-operator-system", m.cfg.WorkingNamespace)
+	assert.Len(t, info.ContainerConfig.Shares, 1)
+	assert.Contains(t, info.ConfigDiff, "Stuff")
+	kinds := []string{}
+	for _, o := range info.Objects {
+		kinds = append(kinds, o.Kind)
+		assert.False(t, o.Exists)
+		assert.Equal(t, "s1", o.Name)
+	}
+	assert.Equal(t,
+		[]string{"Deployment", "PodDisruptionBudget", "Service"}, kinds)
+
+	// an existing deployment that differs from the desired one
+	dep := info.Objects[0].Desired.(*appsv1.Deployment).DeepCopy()
+	replicas := int32(3)
+	dep.Spec.Replicas = &replicas
+	require.NoError(t, m.client.Create(ctx, dep))
+	info, err = m.DebugShare(ctx, nsname)
+	require.NoError(t, err)
+	assert.True(t, info.Objects[0].Exists)
+	assert.Contains(t, info.Objects[0].Diff, "replicas")
+	assert.Empty(t, info.Objects[1].Diff)
+
+	_, err = m.DebugShare(ctx, types.NamespacedName{Namespace: "dtest"})
+	assert.Error(t, err)
+}
+
+func TestPruneTo(t *testing.T) {
+	desired := map[string]interface{}{
+		"replicas": 1.0,
+		"ports":    []interface{}{map[string]interface{}{"port": 445.0}},
+	}
+	current := map[string]interface{}{
+		"replicas":  2.0,
+		"clusterIP": "10.0.0.1",
+		"ports": []interface{}{
+			map[string]interface{}{"port": 445.0, "protocol": "TCP"},
+		},
+	}
+	assert.Equal(t,
+		map[string]interface{}{
+			"replicas": 2.0,
+			"ports":    []interface{}{map[string]interface{}{"port": 445.0}},
+		},
+		pruneTo(desired, current))
+}
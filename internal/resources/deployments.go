@@ -56,6 +56,8 @@ func buildDeployment(cfg *conf.OperatorConfig,
 			},
 		},
 	}
+	setPasswordRotation(
+		&deployment.Spec.Template.ObjectMeta, planner.passwordRotation)
 	return deployment
 }
 
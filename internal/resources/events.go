@@ -15,6 +15,10 @@ limitations under the License.
 
 package resources
 
+import (
+	goerrors "errors"
+)
+
 // constants for event types.
 // its a bit odd that this isn't already in one of the k8s pkgs
 const (
@@ -27,4 +31,108 @@ const (
 	ReasonCreatedPersistentVolumeClaim = "CreatedPersistentVolumeClaim"
 	ReasonCreatedDeployment            = "CreatedDeployment"
 	ReasonCreatedStatefulSet           = "CreatedStatefulSet"
+	ReasonCreatedPodDisruptionBudget   = "CreatedPodDisruptionBudget"
+	ReasonUpdatedDebugLevels           = "UpdatedDebugLevels"
+	ReasonUpdatedContainers            = "UpdatedContainers"
+	ReasonUpdatedAuditLog              = "UpdatedAuditLog"
+	ReasonCreatedConfigMap             = "CreatedConfigMap"
+	ReasonUpdatedConfig                = "UpdatedConfig"
+	ReasonCreatedService               = "CreatedService"
+	ReasonDeletedService               = "DeletedService"
+	ReasonUpdatedPodDisruptionBudget   = "UpdatedPodDisruptionBudget"
+	ReasonResizedDeployment            = "ResizedDeployment"
+	ReasonFinalized                    = "Finalized"
+	ReasonCopiedSecrets                = "CopiedSecrets"
+	ReasonUpdatedUsersSecret           = "UpdatedUsersSecret"
+	ReasonDeletedServerResources       = "DeletedServerResources"
+	ReasonCompiledUsers                = "CompiledUsers"
+	ReasonRotatedPasswords             = "RotatedPasswords"
+)
+
+// maxEventChanges limits the number of configuration changes described
+// in an event.
+const maxEventChanges = 5
+
+// constants for warning event reasons.
+const (
+	ReasonInvalidPodSettings                = "InvalidPodSettings"
+	ReasonInvalidStatePVCSettings           = "InvalidStatePVCSettings"
+	ReasonMissingSecurityConfig             = "MissingSecurityConfig"
+	ReasonMissingCommonConfig               = "MissingCommonConfig"
+	ReasonClusteringNotEnabled              = "ClusteringNotEnabled"
+	ReasonBackendChangeRefused              = "BackendChangeRefused"
+	ReasonFailedAddFinalizer                = "FailedAddFinalizer"
+	ReasonFailedSetServerGroup              = "FailedSetServerGroup"
+	ReasonFailedUpdateConfig                = "FailedUpdateConfig"
+	ReasonFailedUpdatePersistentVolumeClaim = "FailedUpdatePersistentVolumeClaim"
+	ReasonFailedSetBackend                  = "FailedSetBackend"
+	ReasonFailedCopySecrets                 = "FailedCopySecrets"
+	ReasonFailedUpdateUsersSecret           = "FailedUpdateUsersSecret"
+	ReasonInvalidUsersSecret                = "InvalidUsersSecret"
+	ReasonPlaintextPasswordsRejected        = "PlaintextPasswordsRejected"
+	ReasonInvalidShareAccess                = "InvalidShareAccess"
+	ReasonGroupMembershipsUnsupported       = "GroupMembershipsUnsupported"
+	ReasonFailedCompileUsers                = "FailedCompileUsers"
+	ReasonInvalidSmbUser                    = "InvalidSmbUser"
+	ReasonInvalidSmbGroup                   = "InvalidSmbGroup"
+	ReasonFailedUpdateStatefulSet           = "FailedUpdateStatefulSet"
+	ReasonFailedUpdateDeployment            = "FailedUpdateDeployment"
+	ReasonFailedUpdatePodDisruptionBudget   = "FailedUpdatePodDisruptionBudget"
+	ReasonFailedUpdateService               = "FailedUpdateService"
+	ReasonFailedFinalize                    = "FailedFinalize"
+	ReasonReconcileFailed                   = "ReconcileFailed"
+	ReasonResourceConflict                  = "ResourceConflict"
 )
+
+// stepFailureReasons maps the steps of SmbShareManager.Update, and the
+// finalization of a share, to the reason of the warning event recorded
+// when the step fails.
+var stepFailureReasons = map[string]string{
+	"finalize":              ReasonFailedFinalize,
+	"finalizer":             ReasonFailedAddFinalizer,
+	"server-group":          ReasonFailedSetServerGroup,
+	"configmap":             ReasonFailedUpdateConfig,
+	"pvc":                   ReasonFailedUpdatePersistentVolumeClaim,
+	"backend":               ReasonFailedSetBackend,
+	"secrets":               ReasonFailedCopySecrets,
+	"users-secret":          ReasonFailedUpdateUsersSecret,
+	"state-pvc":             ReasonFailedUpdatePersistentVolumeClaim,
+	"statefulset":           ReasonFailedUpdateStatefulSet,
+	"deployment":            ReasonFailedUpdateDeployment,
+	"pod-disruption-budget": ReasonFailedUpdatePodDisruptionBudget,
+	"service":               ReasonFailedUpdateService,
+	"metrics-service":       ReasonFailedUpdateService,
+}
+
+// reasonError associates an error with the reason of the warning event
+// that reports it, for failures that deserve a more specific reason than
+// the step they occur in.
+type reasonError struct {
+	reason string
+	err    error
+}
+
+func (e reasonError) Error() string {
+	return e.err.Error()
+}
+
+func (e reasonError) Unwrap() error {
+	return e.err
+}
+
+func withReason(reason string, err error) error {
+	return reasonError{reason: reason, err: err}
+}
+
+// failureReason returns the reason of the warning event for an error
+// that occurred in the given step.
+func failureReason(step string, err error) string {
+	var re reasonError
+	if goerrors.As(err, &re) {
+		return re.reason
+	}
+	if reason, found := stepFailureReasons[step]; found {
+		return reason
+	}
+	return ReasonReconcileFailed
+}
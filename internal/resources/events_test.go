@@ -0,0 +1,68 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+)
+
+func TestFailureReason(t *testing.T) {
+	err := fmt.Errorf("boom")
+	assert.Equal(t,
+		ReasonFailedUpdateConfig, failureReason("configmap", err))
+	assert.Equal(t,
+		ReasonFailedUpdateService, failureReason("metrics-service", err))
+	assert.Equal(t,
+		ReasonFailedFinalize, failureReason("finalize", err))
+	assert.Equal(t,
+		ReasonReconcileFailed, failureReason("", err))
+
+	// a specific reason wins over the step, even when wrapped again
+	err = fmt.Errorf("outer: %w",
+		withReason(ReasonBackendChangeRefused, err))
+	assert.Equal(t,
+		ReasonBackendChangeRefused, failureReason("backend", err))
+	assert.Equal(t, "outer: boom", err.Error())
+}
+
+func TestRecordFailure(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	m := &SmbShareManager{recorder: recorder}
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "etest", Name: "s1"},
+	}
+
+	m.recordFailure(share, ReasonFailedUpdateService, fmt.Errorf("boom"))
+	if assert.Len(t, recorder.Events, 1) {
+		assert.Equal(t,
+			"Warning FailedUpdateService boom", <-recorder.Events)
+	}
+
+	// conflicts are retried without an event
+	conflict := errors.NewConflict(
+		schema.GroupResource{Resource: "smbshares"}, "s1", fmt.Errorf("x"))
+	m.recordFailure(share, ReasonFailedSetBackend, conflict)
+	assert.Len(t, recorder.Events, 0)
+}
@@ -0,0 +1,324 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	goerrors "errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+)
+
+const metricsNamespace = "samba_operator"
+
+// share phases reported by the shares metric.
+const (
+	sharePhasePending  = "Pending"
+	sharePhaseReady    = "Ready"
+	sharePhaseError    = "Error"
+	sharePhaseDeleting = "Deleting"
+)
+
+var (
+	reconcileStepDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "reconcile_step_duration_seconds",
+			Help:      "Time spent in each step of reconciling a SmbShare.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"namespace", "server_group", "step"})
+	reconcileRequeues = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "reconcile_requeues_total",
+			Help:      "Number of SmbShare reconciles requeued, by step.",
+		},
+		[]string{"namespace", "server_group", "step"})
+	apiErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "api_errors_total",
+			Help:      "Number of API errors while reconciling SmbShares.",
+		},
+		[]string{"namespace", "server_group", "kind"})
+	configSize = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "config_size_bytes",
+			Help:      "Size of the samba container config of a server group.",
+		},
+		[]string{"namespace", "server_group"})
+	shareStates = newShareStateCollector()
+	groupLabels = newGroupLabelTracker()
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileStepDuration,
+		reconcileRequeues,
+		apiErrors,
+		configSize,
+		shareStates,
+	)
+}
+
+// reconcileMetrics records the metrics of a single reconcile of a
+// SmbShare. The reconcile is divided into steps, each of which manages
+// resources of one kind.
+type reconcileMetrics struct {
+	share        *sambaoperatorv1alpha1.SmbShare
+	securityMode string
+	step         string
+	kind         string
+	start        time.Time
+}
+
+func newReconcileMetrics(
+	s *sambaoperatorv1alpha1.SmbShare) *reconcileMetrics {
+	// ---
+	return &reconcileMetrics{share: s}
+}
+
+// begin ends the current step, if any, and starts a new one.
+func (rm *reconcileMetrics) begin(step, kind string) {
+	rm.end()
+	rm.step = step
+	rm.kind = kind
+	rm.start = time.Now()
+}
+
+func (rm *reconcileMetrics) end() {
+	if rm.step == "" {
+		return
+	}
+	groupLabels.addStep(rm.share, rm.step)
+	reconcileStepDuration.WithLabelValues(
+		rm.share.Namespace,
+		rm.share.Status.ServerGroup,
+		rm.step,
+	).Observe(time.Since(rm.start).Seconds())
+	rm.step = ""
+}
+
+// finish ends the reconcile, accounting the result to the last step.
+func (rm *reconcileMetrics) finish(res Result) {
+	step, kind := rm.step, rm.kind
+	rm.end()
+	ns, group := rm.share.Namespace, rm.share.Status.ServerGroup
+	if res.Requeue() {
+		groupLabels.addStep(rm.share, step)
+		reconcileRequeues.WithLabelValues(ns, group, step).Inc()
+	}
+	if isAPIError(res.Err()) {
+		groupLabels.addKind(rm.share, kind)
+		apiErrors.WithLabelValues(ns, group, kind).Inc()
+	}
+}
+
+// observeConfig records the size of the container config of the share's
+// server group.
+func (rm *reconcileMetrics) observeConfig(cm *corev1.ConfigMap) {
+	configSize.WithLabelValues(
+		rm.share.Namespace,
+		rm.share.Status.ServerGroup,
+	).Set(float64(len(cm.Data[ConfigJSONKey])))
+}
+
+func isAPIError(err error) bool {
+	var status errors.APIStatus
+	return err != nil && goerrors.As(err, &status)
+}
+
+type groupKey struct {
+	namespace   string
+	serverGroup string
+}
+
+// groupLabelTracker remembers the steps and kinds that label the per
+// server group metrics, so that all series of a server group can be
+// deleted once the group is gone.
+type groupLabelTracker struct {
+	lock  sync.Mutex
+	steps map[groupKey]map[string]bool
+	kinds map[groupKey]map[string]bool
+}
+
+func newGroupLabelTracker() *groupLabelTracker {
+	return &groupLabelTracker{
+		steps: map[groupKey]map[string]bool{},
+		kinds: map[groupKey]map[string]bool{},
+	}
+}
+
+func (t *groupLabelTracker) addStep(
+	s *sambaoperatorv1alpha1.SmbShare, step string) {
+	// ---
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	addLabel(t.steps, groupKey{s.Namespace, s.Status.ServerGroup}, step)
+}
+
+func (t *groupLabelTracker) addKind(
+	s *sambaoperatorv1alpha1.SmbShare, kind string) {
+	// ---
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	addLabel(t.kinds, groupKey{s.Namespace, s.Status.ServerGroup}, kind)
+}
+
+func addLabel(m map[groupKey]map[string]bool, k groupKey, v string) {
+	if m[k] == nil {
+		m[k] = map[string]bool{}
+	}
+	m[k][v] = true
+}
+
+// deleteGroup deletes the series of every per server group metric of the
+// given server group.
+func (t *groupLabelTracker) deleteGroup(k groupKey) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for step := range t.steps[k] {
+		reconcileStepDuration.DeleteLabelValues(k.namespace, k.serverGroup, step)
+		reconcileRequeues.DeleteLabelValues(k.namespace, k.serverGroup, step)
+	}
+	for kind := range t.kinds[k] {
+		apiErrors.DeleteLabelValues(k.namespace, k.serverGroup, kind)
+	}
+	configSize.DeleteLabelValues(k.namespace, k.serverGroup)
+	delete(t.steps, k)
+	delete(t.kinds, k)
+}
+
+type shareState struct {
+	serverGroup  string
+	phase        string
+	backend      string
+	securityMode string
+}
+
+// shareStateCollector reports the number of SmbShares by phase, backend
+// and security mode. The state of each share is tracked individually so
+// that a share changing phase is never counted twice.
+type shareStateCollector struct {
+	lock   sync.Mutex
+	shares map[types.NamespacedName]shareState
+	desc   *prometheus.Desc
+}
+
+func newShareStateCollector() *shareStateCollector {
+	return &shareStateCollector{
+		shares: map[types.NamespacedName]shareState{},
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "shares"),
+			"Number of SmbShares by phase, backend and security mode.",
+			[]string{
+				"namespace",
+				"server_group",
+				"phase",
+				"backend",
+				"security_mode",
+			},
+			nil),
+	}
+}
+
+// set records the state of a share.
+func (c *shareStateCollector) set(
+	s *sambaoperatorv1alpha1.SmbShare,
+	phase, securityMode string) {
+	// ---
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	nsname := types.NamespacedName{Namespace: s.Namespace, Name: s.Name}
+	if securityMode == "" {
+		// keep the last known security mode when it was not determined
+		securityMode = c.shares[nsname].securityMode
+	}
+	c.shares[nsname] = shareState{
+		serverGroup:  s.Status.ServerGroup,
+		phase:        phase,
+		backend:      s.Annotations[serverBackend],
+		securityMode: securityMode,
+	}
+}
+
+// forget stops tracking a share. When no other tracked share belongs to
+// the share's server group, the metrics of the server group are dropped.
+func (c *shareStateCollector) forget(nsname types.NamespacedName) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	st, found := c.shares[nsname]
+	if !found {
+		return
+	}
+	delete(c.shares, nsname)
+	for other, ost := range c.shares {
+		if other.Namespace == nsname.Namespace &&
+			ost.serverGroup == st.serverGroup {
+			return
+		}
+	}
+	groupLabels.deleteGroup(groupKey{nsname.Namespace, st.serverGroup})
+}
+
+// Describe implements prometheus.Collector.
+func (c *shareStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *shareStateCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	type key struct {
+		namespace string
+		shareState
+	}
+	counts := map[key]int{}
+	for nsname, st := range c.shares {
+		counts[key{nsname.Namespace, st}]++
+	}
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(
+			c.desc,
+			prometheus.GaugeValue,
+			float64(n),
+			k.namespace,
+			k.serverGroup,
+			k.phase,
+			k.backend,
+			k.securityMode)
+	}
+}
+
+func sharePhase(res Result) string {
+	switch {
+	case res.Err() != nil:
+		return sharePhaseError
+	case res.Requeue():
+		return sharePhasePending
+	}
+	return sharePhaseReady
+}
@@ -0,0 +1,137 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+)
+
+func TestReconcileMetrics(t *testing.T) {
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "mtest", Name: "s1"},
+		Status:     sambaoperatorv1alpha1.SmbShareStatus{ServerGroup: "s1"},
+	}
+
+	rm := newReconcileMetrics(share)
+	rm.begin("configmap", "ConfigMap")
+	rm.observeConfig(&corev1.ConfigMap{
+		Data: map[string]string{ConfigJSONKey: "{}"},
+	})
+	rm.begin("deployment", "Deployment")
+	rm.finish(Requeue)
+	assert.Equal(t, 1.0, testutil.ToFloat64(
+		reconcileRequeues.WithLabelValues("mtest", "s1", "deployment")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(
+		configSize.WithLabelValues("mtest", "s1")))
+
+	rm = newReconcileMetrics(share)
+	rm.begin("service", "Service")
+	rm.finish(Result{err: fmt.Errorf("not an api error")})
+	rm = newReconcileMetrics(share)
+	rm.begin("service", "Service")
+	rm.finish(Result{err: errors.NewForbidden(
+		schema.GroupResource{Resource: "services"}, "s1", nil)})
+	assert.Equal(t, 1.0, testutil.ToFloat64(
+		apiErrors.WithLabelValues("mtest", "s1", "Service")))
+}
+
+func TestShareStateCollector(t *testing.T) {
+	c := newShareStateCollector()
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ctest", Name: "s1"},
+	}
+	c.set(share, sharePhasePending, "user")
+	assert.Equal(t, 1, testutil.CollectAndCount(c))
+	c.set(share, sharePhaseReady, "")
+	assert.Equal(t, 1, testutil.CollectAndCount(c))
+	assert.Equal(t, "user", c.shares[types.NamespacedName{
+		Namespace: "ctest", Name: "s1"}].securityMode)
+
+	share2 := share.DeepCopy()
+	share2.Name = "s2"
+	c.set(share2, sharePhaseReady, "user")
+	assert.Equal(t, 1, testutil.CollectAndCount(c))
+	assert.Equal(t, 2.0, testutil.ToFloat64(c))
+
+	c.forget(types.NamespacedName{Namespace: "ctest", Name: "s1"})
+	assert.Equal(t, 1.0, testutil.ToFloat64(c))
+}
+
+func TestForgetShareMetrics(t *testing.T) {
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ftest", Name: "s1"},
+		Status:     sambaoperatorv1alpha1.SmbShareStatus{ServerGroup: "g1"},
+	}
+	share2 := share.DeepCopy()
+	share2.Name = "s2"
+	for _, s := range []*sambaoperatorv1alpha1.SmbShare{share, share2} {
+		shareStates.set(s, sharePhaseReady, "user")
+		rm := newReconcileMetrics(s)
+		rm.begin("configmap", "ConfigMap")
+		rm.observeConfig(&corev1.ConfigMap{
+			Data: map[string]string{ConfigJSONKey: "{}"},
+		})
+		rm.begin("service", "Service")
+		rm.finish(Result{err: errors.NewForbidden(
+			schema.GroupResource{Resource: "services"}, "g1", nil)})
+		rm = newReconcileMetrics(s)
+		rm.begin("deployment", "Deployment")
+		rm.finish(Requeue)
+	}
+
+	// series of the server group found in the registry, by metric name
+	groupSeries := func() map[string]int {
+		families, err := metrics.Registry.Gather()
+		assert.NoError(t, err)
+		found := map[string]int{}
+		for _, mf := range families {
+			for _, m := range mf.GetMetric() {
+				for _, l := range m.GetLabel() {
+					if l.GetName() == "namespace" && l.GetValue() == "ftest" {
+						found[mf.GetName()]++
+					}
+				}
+			}
+		}
+		return found
+	}
+	assert.Equal(t, map[string]int{
+		"samba_operator_reconcile_step_duration_seconds": 3,
+		"samba_operator_reconcile_requeues_total":        1,
+		"samba_operator_api_errors_total":                1,
+		"samba_operator_config_size_bytes":               1,
+		"samba_operator_shares":                          1,
+	}, groupSeries())
+
+	// the server group still hosts s2
+	shareStates.forget(types.NamespacedName{Namespace: "ftest", Name: "s1"})
+	assert.Len(t, groupSeries(), 5)
+
+	shareStates.forget(types.NamespacedName{Namespace: "ftest", Name: "s2"})
+	assert.Empty(t, groupSeries())
+}
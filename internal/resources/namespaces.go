@@ -0,0 +1,277 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+)
+
+const (
+	// OwnerAnnotation records the namespace and name of the SmbShare that
+	// owns a resource in another namespace, where owner references can not
+	// be used.
+	OwnerAnnotation = "samba-operator.samba.org/owner"
+	// ownerServerGroupLabel records the server group of the SmbShare that
+	// owns a resource in another namespace.
+	ownerServerGroupLabel = "samba-operator.samba.org/owner-server-group"
+)
+
+// maxServerGroupLen limits the length of server group names made up of
+// the namespace and name of a share, so that the names of the resources
+// derived from them remain valid.
+const maxServerGroupLen = 48
+
+// serverGroupHashLen is the number of hex digits of the hash that makes
+// server group names in the working namespace unique.
+const serverGroupHashLen = 10
+
+// serverNamespace returns the namespace the resources hosting the share
+// are created in.
+func (m *SmbShareManager) serverNamespace(
+	s *sambaoperatorv1alpha1.SmbShare) string {
+	// ---
+	if m.cfg.ServerNamespaceMode == conf.ServerNamespaceWorking {
+		return m.cfg.WorkingNamespace
+	}
+	return s.Namespace
+}
+
+// serverGroupName returns the name of a new server group for the share.
+// Servers of shares from many namespaces may share the working namespace,
+// so their names end with a hash of the namespace and name of the share,
+// following a readable prefix made up of both.
+func (m *SmbShareManager) serverGroupName(
+	s *sambaoperatorv1alpha1.SmbShare) string {
+	// ---
+	if m.serverNamespace(s) == s.Namespace {
+		return s.Name
+	}
+	sum := sha256.Sum256([]byte(s.Namespace + "/" + s.Name))
+	prefix := s.Namespace + "-" + s.Name
+	if maxLen := maxServerGroupLen - serverGroupHashLen - 1; len(prefix) > maxLen {
+		prefix = strings.TrimRight(prefix[:maxLen], "-")
+	}
+	return prefix + "-" + hex.EncodeToString(sum[:])[:serverGroupHashLen]
+}
+
+// setOwner marks the share as the owner of the object. Objects in the
+// namespace of the share get an owner reference, so that they are garbage
+// collected with the share. Owner references can not cross namespaces, so
+// other objects are labeled with the server group and annotated with the
+// share instead; they are deleted when the share is finalized.
+func (m *SmbShareManager) setOwner(
+	s *sambaoperatorv1alpha1.SmbShare, obj metav1.Object) error {
+	// ---
+	if obj.GetNamespace() == s.Namespace {
+		return controllerutil.SetControllerReference(s, obj, m.scheme)
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[ownerServerGroupLabel] = s.Status.ServerGroup
+	obj.SetLabels(labels)
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[OwnerAnnotation] = s.Namespace + "/" + s.Name
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// ownedBy returns true if the object belongs to the share. Objects in
+// other namespaces than the share's are looked up by name in a namespace
+// shared by many shares, so they only belong to the share they are
+// annotated with.
+func ownedBy(s *sambaoperatorv1alpha1.SmbShare, obj metav1.Object) bool {
+	if obj.GetNamespace() == s.Namespace {
+		return true
+	}
+	owner, found := OwnerFromAnnotation(obj)
+	return found && owner.Namespace == s.Namespace && owner.Name == s.Name
+}
+
+// checkOwner returns an error if an existing object does not belong to
+// the share, so that a share never adopts the resources of another.
+func (m *SmbShareManager) checkOwner(
+	s *sambaoperatorv1alpha1.SmbShare, obj metav1.Object) error {
+	// ---
+	if ownedBy(s, obj) {
+		return nil
+	}
+	err := fmt.Errorf(
+		"%s/%s is not owned by SmbShare %s/%s",
+		obj.GetNamespace(), obj.GetName(), s.Namespace, s.Name)
+	m.logger.Error(err, "Resource name conflict",
+		"SmbShare.Namespace", s.Namespace,
+		"SmbShare.Name", s.Name,
+		"Object.Namespace", obj.GetNamespace(),
+		"Object.Name", obj.GetName(),
+		"Object.Owner", obj.GetAnnotations()[OwnerAnnotation])
+	return withReason(ReasonResourceConflict, err)
+}
+
+// OwnerFromAnnotation returns the share owning an object in another
+// namespace, if any.
+func OwnerFromAnnotation(obj metav1.Object) (types.NamespacedName, bool) {
+	parts := strings.SplitN(obj.GetAnnotations()[OwnerAnnotation], "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, true
+}
+
+// syncSecrets copies the secrets referenced by the share's configuration to
+// the namespace of the pods, if it is not the namespace of the share. It
+// returns true if a copy was created or updated.
+func (m *SmbShareManager) syncSecrets(
+	ctx context.Context,
+	planner *sharePlanner,
+	ns string) (bool, error) {
+	// ---
+	if ns == planner.SmbShare.Namespace {
+		return false, nil
+	}
+	changed := false
+	for _, name := range planner.referencedSecrets() {
+		src := &corev1.Secret{}
+		srcKey := types.NamespacedName{
+			Namespace: planner.SmbShare.Namespace,
+			Name:      name,
+		}
+		if err := m.client.Get(ctx, srcKey, src); err != nil {
+			m.logger.Error(err, "Failed to get Secret",
+				"Secret.Namespace", srcKey.Namespace,
+				"Secret.Name", srcKey.Name)
+			return false, err
+		}
+		dst := &corev1.Secret{}
+		dstKey := types.NamespacedName{
+			Namespace: ns,
+			Name:      planner.podSecretName(name),
+		}
+		err := m.client.Get(ctx, dstKey, dst)
+		if errors.IsNotFound(err) {
+			dst = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      dstKey.Name,
+					Namespace: dstKey.Namespace,
+				},
+				Type: src.Type,
+				Data: src.Data,
+			}
+			if err = m.setOwner(planner.SmbShare, dst); err != nil {
+				return false, err
+			}
+			m.logger.Info("Copying Secret",
+				"Secret.Namespace", dst.Namespace,
+				"Secret.Name", dst.Name)
+			if err = m.client.Create(ctx, dst); err != nil {
+				return false, err
+			}
+			changed = true
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		if err = m.checkOwner(planner.SmbShare, dst); err != nil {
+			return false, err
+		}
+		if reflect.DeepEqual(dst.Data, src.Data) {
+			continue
+		}
+		dst.Data = src.Data
+		m.logger.Info("Updating copy of Secret",
+			"Secret.Namespace", dst.Namespace,
+			"Secret.Name", dst.Name)
+		if err = m.client.Update(ctx, dst); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// deleteOwnedResources deletes the resources hosting the share that are
+// not in the namespace of the share, and so are not garbage collected with
+// it. It returns true if any resource was deleted.
+func (m *SmbShareManager) deleteOwnedResources(
+	ctx context.Context,
+	s *sambaoperatorv1alpha1.SmbShare,
+	ns string) (bool, error) {
+	// ---
+	if ns == s.Namespace || s.Status.ServerGroup == "" {
+		return false, nil
+	}
+	lists := []rtclient.ObjectList{
+		&appsv1.DeploymentList{},
+		&appsv1.StatefulSetList{},
+		&policyv1.PodDisruptionBudgetList{},
+		&corev1.ServiceList{},
+		&corev1.ConfigMapList{},
+		&corev1.PersistentVolumeClaimList{},
+		&corev1.SecretList{},
+	}
+	deleted := false
+	owner := s.Namespace + "/" + s.Name
+	for _, list := range lists {
+		err := m.client.List(ctx, list,
+			rtclient.InNamespace(ns),
+			rtclient.MatchingLabels{ownerServerGroupLabel: s.Status.ServerGroup})
+		if err != nil {
+			return false, err
+		}
+		objs, err := meta.ExtractList(list)
+		if err != nil {
+			return false, err
+		}
+		for _, o := range objs {
+			obj := o.(rtclient.Object)
+			if obj.GetAnnotations()[OwnerAnnotation] != owner {
+				continue
+			}
+			m.logger.Info("Deleting resource of SmbShare",
+				"Object.Namespace", obj.GetNamespace(),
+				"Object.Name", obj.GetName())
+			err := m.client.Delete(ctx, obj,
+				rtclient.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !errors.IsNotFound(err) {
+				return false, err
+			}
+			deleted = true
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,290 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+func TestServerNamespace(t *testing.T) {
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a", Name: "s1"},
+	}
+	m := &SmbShareManager{cfg: &conf.OperatorConfig{
+		WorkingNamespace: "samba-operator-system",
+	}}
+	assert.Equal(t, "tenant-a", m.serverNamespace(share))
+	assert.Equal(t, "s1", m.serverGroupName(share))
+
+	m.cfg.ServerNamespaceMode = conf.ServerNamespaceWorking
+	assert.Equal(t, "samba-operator-system", m.serverNamespace(share))
+	name := m.serverGroupName(share)
+	assert.True(t, strings.HasPrefix(name, "tenant-a-s1-"))
+	assert.Len(t, name, len("tenant-a-s1-")+serverGroupHashLen)
+
+	// namespaces and names joining to the same prefix differ
+	other := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "a-s1"},
+	}
+	assert.NotEqual(t, name, m.serverGroupName(other))
+
+	share.Name = strings.Repeat("x", 60)
+	name = m.serverGroupName(share)
+	assert.Len(t, name, maxServerGroupLen)
+	assert.True(t, strings.HasPrefix(name, "tenant-a-xxx"))
+	share.Name = strings.Repeat("x", 59) + "y"
+	assert.NotEqual(t, name, m.serverGroupName(share))
+}
+
+func TestCheckOwner(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a", Name: "s1"},
+		Status: sambaoperatorv1alpha1.SmbShareStatus{
+			ServerGroup: "s1",
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "central",
+			Name:      "s1",
+			Annotations: map[string]string{
+				OwnerAnnotation: "tenant-b/s1",
+			},
+		},
+	}
+	cfg := &conf.OperatorConfig{
+		WorkingNamespace:    "central",
+		ServerNamespaceMode: conf.ServerNamespaceWorking,
+	}
+	m := &SmbShareManager{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(svc).
+			Build(),
+		scheme: scheme,
+		logger: logr.Discard(),
+		cfg:    cfg,
+	}
+	planner := newSharePlanner(InstanceConfiguration{
+		SmbShare:     share,
+		GlobalConfig: cfg,
+	}, smbcc.New())
+	ctx := context.TODO()
+	_, _, err := m.getOrCreateService(ctx, planner, "central")
+	require.Error(t, err)
+	assert.Equal(t, ReasonResourceConflict, failureReason("service", err))
+
+	svc.Annotations[OwnerAnnotation] = "tenant-a/s1"
+	assert.NoError(t, m.checkOwner(share, svc))
+	local := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a", Name: "s1"},
+	}
+	assert.NoError(t, m.checkOwner(share, local))
+}
+
+func TestSetOwner(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "tenant-a",
+			Name:      "s1",
+			UID:       "0b1c",
+		},
+		Status: sambaoperatorv1alpha1.SmbShareStatus{
+			ServerGroup: "tenant-a-s1",
+		},
+	}
+	m := &SmbShareManager{scheme: scheme}
+
+	local := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a", Name: "cm"},
+	}
+	require.NoError(t, m.setOwner(share, local))
+	assert.Len(t, local.OwnerReferences, 1)
+	_, found := OwnerFromAnnotation(local)
+	assert.False(t, found)
+
+	remote := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "central", Name: "cm"},
+	}
+	require.NoError(t, m.setOwner(share, remote))
+	assert.Len(t, remote.OwnerReferences, 0)
+	assert.Equal(t, "tenant-a-s1", remote.Labels[ownerServerGroupLabel])
+	nsname, found := OwnerFromAnnotation(remote)
+	assert.True(t, found)
+	assert.Equal(t,
+		types.NamespacedName{Namespace: "tenant-a", Name: "s1"}, nsname)
+}
+
+func TestCentralizedSecrets(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a", Name: "s1"},
+		Status: sambaoperatorv1alpha1.SmbShareStatus{
+			ServerGroup: "tenant-a-s1",
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a", Name: "join1"},
+		Data:       map[string][]byte{"join.json": []byte("{}")},
+	}
+	cfg := &conf.OperatorConfig{
+		WorkingNamespace:    "central",
+		ServerNamespaceMode: conf.ServerNamespaceWorking,
+	}
+	planner := newSharePlanner(InstanceConfiguration{
+		SmbShare: share,
+		SecurityConfig: &sambaoperatorv1alpha1.SmbSecurityConfig{
+			Spec: sambaoperatorv1alpha1.SmbSecurityConfigSpec{
+				Mode:  "active-directory",
+				Realm: "example.org",
+				JoinSources: []sambaoperatorv1alpha1.SmbSecurityJoinSpec{{
+					UserJoin: &sambaoperatorv1alpha1.SmbSecurityUserJoinSpec{
+						Secret: "join1",
+						Key:    "join.json",
+					},
+				}},
+			},
+		},
+		GlobalConfig: cfg,
+	}, smbcc.New())
+	assert.Equal(t, []string{"join1"}, planner.referencedSecrets())
+	assert.Equal(t, "tenant-a-s1-join1", planner.podSecretName("join1"))
+
+	m := &SmbShareManager{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(share, secret).
+			Build(),
+		scheme: scheme,
+		logger: logr.Discard(),
+		cfg:    cfg,
+	}
+	ctx := context.TODO()
+	changed, err := m.syncSecrets(ctx, planner, "central")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = m.syncSecrets(ctx, planner, "central")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	copied := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: "central", Name: "tenant-a-s1-join1"}
+	require.NoError(t, m.client.Get(ctx, key, copied))
+	assert.Equal(t, secret.Data, copied.Data)
+
+	deleted, err := m.deleteOwnedResources(ctx, share, "central")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = m.deleteOwnedResources(ctx, share, "central")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestCentralizedPodResources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	newShare := func(ns string) *sambaoperatorv1alpha1.SmbShare {
+		return &sambaoperatorv1alpha1.SmbShare{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "data"},
+			Spec: sambaoperatorv1alpha1.SmbShareSpec{
+				Storage: sambaoperatorv1alpha1.SmbShareStorageSpec{
+					Pvc: &sambaoperatorv1alpha1.SmbSharePvcSpec{
+						Spec: &corev1.PersistentVolumeClaimSpec{},
+					},
+				},
+			},
+			Status: sambaoperatorv1alpha1.SmbShareStatus{
+				ServerGroup: ns + "-data",
+			},
+		}
+	}
+	cfg := &conf.OperatorConfig{
+		WorkingNamespace:    "central",
+		ServerNamespaceMode: conf.ServerNamespaceWorking,
+	}
+	planA := newSharePlanner(InstanceConfiguration{
+		SmbShare:     newShare("tenant-a"),
+		GlobalConfig: cfg,
+	}, smbcc.New())
+	planB := newSharePlanner(InstanceConfiguration{
+		SmbShare:     newShare("tenant-b"),
+		GlobalConfig: cfg,
+	}, smbcc.New())
+	assert.Equal(t, "tenant-a-data-pvc", pvcName(planA))
+	assert.NotEqual(t, pvcName(planA), pvcName(planB))
+
+	m := &SmbShareManager{
+		client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+		scheme: scheme,
+		logger: logr.Discard(),
+		cfg:    cfg,
+	}
+	ctx := context.TODO()
+	_, created, err := m.getOrCreatePvc(ctx, planA, "central")
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	// a share can not name the PVC of another share
+	planB.SmbShare.Spec.Storage.Pvc = &sambaoperatorv1alpha1.SmbSharePvcSpec{
+		Name: pvcName(planA),
+	}
+	err = m.checkNamedPvc(ctx, planB, "central")
+	assert.Error(t, err)
+	assert.Equal(t, ReasonResourceConflict, failureReason("pvc", err))
+	planA.SmbShare.Spec.Storage.Pvc.Name = pvcName(planA)
+	assert.NoError(t, m.checkNamedPvc(ctx, planA, "central"))
+
+	assert.NoError(t, checkPodExtras(planA))
+	planA.SmbShare.Spec.PodSettings = &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+		Volumes: []corev1.Volume{{
+			Name: "other",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: "tenant-b-data-smbusers",
+				},
+			},
+		}},
+	}
+	assert.Error(t, checkPodExtras(planA))
+	cfg.ServerNamespaceMode = conf.ServerNamespaceShare
+	assert.NoError(t, checkPodExtras(planA))
+	planB.SmbShare.Spec.Storage.Pvc.Name = ""
+	assert.Equal(t, "data-pvc", pvcName(planB))
+}
@@ -0,0 +1,192 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+)
+
+const (
+	// RotatePasswordAnnotation is set on a SmbUser with a generated
+	// password to request a new password. Each new value of the annotation
+	// generates a new password.
+	RotatePasswordAnnotation = "samba-operator.samba.org/rotate-password"
+
+	// passwordRotationAnnotation records the password rotations applied.
+	// On a generated password secret it holds the value of the rotate
+	// annotation the password was generated for. On users secrets and pod
+	// templates it holds a digest of the rotations of the users, so that
+	// a rotation rolls the pods of the servers.
+	passwordRotationAnnotation = "samba-operator.samba.org/password-rotation"
+
+	generatedUsernameKey = "username"
+	passwordLength       = 32
+	passwordAlphabet     = "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+		"abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// generatePassword returns a random password of passwordLength
+// alphanumeric characters.
+func generatePassword() (string, error) {
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	b := make([]byte, passwordLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// passwordKey returns the key of the password secret holding the password.
+func passwordKey(p *sambaoperatorv1alpha1.SmbUserPasswordSpec) string {
+	if p.Key == "" {
+		return "password"
+	}
+	return p.Key
+}
+
+// smbUserName returns the name of the user on the samba server.
+func smbUserName(u *sambaoperatorv1alpha1.SmbUser) string {
+	if u.Spec.UserName != "" {
+		return u.Spec.UserName
+	}
+	return u.Name
+}
+
+// updateGeneratedPassword creates the password secret of a user with a
+// generated password, or generates a new password if a rotation was
+// requested. It returns the secret, or a problem if the secret exists but
+// is not owned by the user.
+func (m *SmbSecurityConfigManager) updateGeneratedPassword(
+	ctx context.Context,
+	u *sambaoperatorv1alpha1.SmbUser) (*corev1.Secret, string, error) {
+	// ---
+	rotation := u.Annotations[RotatePasswordAnnotation]
+	key := types.NamespacedName{
+		Namespace: u.Namespace,
+		Name:      u.Spec.Password.Secret,
+	}
+	secret := &corev1.Secret{}
+	err := m.client.Get(ctx, key, secret)
+	if errors.IsNotFound(err) {
+		password, err := generatePassword()
+		if err != nil {
+			return nil, "", err
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Annotations: map[string]string{
+					passwordRotationAnnotation: rotation,
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				generatedUsernameKey:         []byte(smbUserName(u)),
+				passwordKey(u.Spec.Password): []byte(password),
+			},
+		}
+		err = controllerutil.SetControllerReference(u, secret, m.scheme)
+		if err != nil {
+			return nil, "", err
+		}
+		m.logger.Info("Creating generated password Secret",
+			"Secret.Namespace", secret.Namespace,
+			"Secret.Name", secret.Name)
+		return secret, "", m.client.Create(ctx, secret)
+	} else if err != nil {
+		return nil, "", err
+	}
+	if !metav1.IsControlledBy(secret, u) {
+		return nil, fmt.Sprintf(
+			"Password secret %s exists and is not owned by the SmbUser",
+			key.Name), nil
+	}
+	_, found := secret.Data[passwordKey(u.Spec.Password)]
+	if found && secret.Annotations[passwordRotationAnnotation] == rotation {
+		return secret, "", nil
+	}
+	password, err := generatePassword()
+	if err != nil {
+		return nil, "", err
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[passwordRotationAnnotation] = rotation
+	secret.Data = map[string][]byte{
+		generatedUsernameKey:         []byte(smbUserName(u)),
+		passwordKey(u.Spec.Password): []byte(password),
+	}
+	m.logger.Info("Rotating generated password",
+		"Secret.Namespace", secret.Namespace,
+		"Secret.Name", secret.Name)
+	return secret, "", m.client.Update(ctx, secret)
+}
+
+// rotationDigest combines the password rotations of the users, by user
+// name, into a single value. It is empty if no password was rotated.
+func rotationDigest(rotations map[string]string) string {
+	var entries []string
+	for name, r := range rotations {
+		if r != "" {
+			entries = append(entries, name+"="+r)
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	sort.Strings(entries)
+	sum := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// setPasswordRotation sets the password rotation annotation of an object,
+// removing it if the rotation is empty. It returns true if the annotation
+// changed.
+func setPasswordRotation(meta *metav1.ObjectMeta, rotation string) bool {
+	if meta.Annotations[passwordRotationAnnotation] == rotation {
+		return false
+	}
+	if rotation == "" {
+		delete(meta.Annotations, passwordRotationAnnotation)
+		return true
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[passwordRotationAnnotation] = rotation
+	return true
+}
@@ -0,0 +1,174 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	p1, err := generatePassword()
+	require.NoError(t, err)
+	p2, err := generatePassword()
+	require.NoError(t, err)
+	assert.Len(t, p1, passwordLength)
+	assert.NotEqual(t, p1, p2)
+	for _, c := range p1 {
+		assert.True(t, strings.ContainsRune(passwordAlphabet, c))
+	}
+}
+
+func TestRotationDigest(t *testing.T) {
+	assert.Equal(t, "", rotationDigest(nil))
+	assert.Equal(t, "", rotationDigest(map[string]string{"a": ""}))
+	d := rotationDigest(map[string]string{"a": "1", "b": ""})
+	assert.Len(t, d, 16)
+	assert.Equal(t, d, rotationDigest(map[string]string{"a": "1"}))
+	assert.NotEqual(t, d, rotationDigest(map[string]string{"a": "2"}))
+}
+
+func TestGeneratedPasswords(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	sc := &sambaoperatorv1alpha1.SmbSecurityConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "utest", Name: "sec"},
+		Spec: sambaoperatorv1alpha1.SmbSecurityConfigSpec{
+			Mode: "user",
+			Users: &sambaoperatorv1alpha1.SmbSecurityUsersSpec{
+				Secret:   "compiled",
+				Key:      "users.json",
+				Selector: &metav1.LabelSelector{},
+			},
+		},
+	}
+	svc := smbUser("svc", sambaoperatorv1alpha1.SmbUserSpec{
+		UserName: "service1",
+		Password: &sambaoperatorv1alpha1.SmbUserPasswordSpec{
+			Secret:   "svc-creds",
+			Generate: true,
+		},
+	})
+	m := NewSmbSecurityConfigManager(
+		fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(sc, &svc).
+			Build(),
+		scheme,
+		record.NewFakeRecorder(10),
+		logr.Discard())
+	ctx := context.TODO()
+	nsname := types.NamespacedName{Namespace: "utest", Name: "sec"}
+	get := func(name string, obj client.Object) {
+		require.NoError(t, m.client.Get(ctx,
+			types.NamespacedName{Namespace: "utest", Name: name}, obj))
+	}
+	compiledHash := func() (string, *corev1.Secret) {
+		compiled := &corev1.Secret{}
+		get("compiled", compiled)
+		cc, _, err := smbcc.Parse(compiled.Data["users.json"])
+		require.NoError(t, err)
+		return cc.Users[smbcc.AllEntriesKey][0].NTHash, compiled
+	}
+
+	require.NoError(t, m.Process(ctx, nsname).Err())
+	creds := &corev1.Secret{}
+	get("svc-creds", creds)
+	assert.True(t, metav1.IsControlledBy(creds, &svc))
+	assert.Equal(t, "service1", string(creds.Data["username"]))
+	password := string(creds.Data["password"])
+	assert.Len(t, password, passwordLength)
+	hash, compiled := compiledHash()
+	assert.Equal(t, smbcc.NTHash(password), hash)
+	assert.NotContains(t, compiled.Annotations, passwordRotationAnnotation)
+
+	// the password is kept across reconciles
+	require.NoError(t, m.Process(ctx, nsname).Err())
+	get("svc-creds", creds)
+	assert.Equal(t, password, string(creds.Data["password"]))
+
+	// a new value of the annotation rotates the password
+	get("svc", &svc)
+	svc.Annotations = map[string]string{RotatePasswordAnnotation: "1"}
+	require.NoError(t, m.client.Update(ctx, &svc))
+	require.NoError(t, m.Process(ctx, nsname).Err())
+	get("svc-creds", creds)
+	assert.NotEqual(t, password, string(creds.Data["password"]))
+	hash, compiled = compiledHash()
+	assert.Equal(t, smbcc.NTHash(string(creds.Data["password"])), hash)
+	rotation := compiled.Annotations[passwordRotationAnnotation]
+	assert.NotEmpty(t, rotation)
+
+	// the rotation is carried over to the share's users secret and pods
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "utest", Name: "s1"},
+		Spec:       sambaoperatorv1alpha1.SmbShareSpec{SecurityConfig: "sec"},
+		Status:     sambaoperatorv1alpha1.SmbShareStatus{ServerGroup: "s1"},
+	}
+	require.NoError(t, m.client.Create(ctx, share))
+	sm := &SmbShareManager{
+		client: m.client,
+		scheme: scheme,
+		logger: logr.Discard(),
+		cfg:    &conf.OperatorConfig{},
+	}
+	planner := newSharePlanner(InstanceConfiguration{
+		SmbShare:       share,
+		SecurityConfig: sc,
+		GlobalConfig:   sm.cfg,
+	}, smbcc.New())
+	deployment := buildDeployment(sm.cfg, planner, "pvc", "utest")
+	require.NoError(t, m.client.Create(ctx, deployment))
+
+	_, err := sm.updateUsersSecret(ctx, planner, "utest")
+	require.NoError(t, err)
+	assert.Equal(t, rotation, planner.passwordRotation)
+	derived := &corev1.Secret{}
+	get("s1-smbusers", derived)
+	assert.Equal(t, rotation, derived.Annotations[passwordRotationAnnotation])
+
+	changed, err := sm.updatePasswordRotation(
+		ctx, planner, deployment, &deployment.Spec.Template)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	stored := &appsv1.Deployment{}
+	get("s1", stored)
+	assert.Equal(t, rotation,
+		stored.Spec.Template.Annotations[passwordRotationAnnotation])
+	changed, err = sm.updatePasswordRotation(
+		ctx, planner, deployment, &deployment.Spec.Template)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
@@ -20,6 +20,9 @@ import (
 	"path"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
 	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
 	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
 	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
@@ -40,17 +43,56 @@ const (
 	dnsRegisterClusterIP  = dnsRegister("cluster-ip")
 )
 
-// "cheat codes"
+type plaintextPasswords string
+
+const (
+	convertPlaintextPasswords = plaintextPasswords("convert")
+	rejectPlaintextPasswords  = plaintextPasswords("reject")
+)
+
+const defaultTopologyKey = "kubernetes.io/hostname"
+
+// nodeSpreadKey is the annotation that disabled node spreading before the
+// nodeSpread field existed. It is honoured when the field does not say
+// otherwise, so that existing shares keep their placement.
 const (
 	nodeSpreadKey     = "samba-operator.samba.org/node-spread"
 	nodeSpreadDisable = "false"
 )
 
+// metricsPort is the port the metrics exporter listens on.
+const metricsPort = 8080
+
+// sambaImageAnnotation may be set on an SmbShare to override the image of
+// the samba containers of its server group.
+const sambaImageAnnotation = "samba-operator.samba.org/samba-image"
+
+const (
+	defaultTerminationGracePeriod = int64(60)
+	drainExitMargin               = int64(10)
+)
+
+// serverComponent identifies a part of the smb server that runs in its own
+// container(s).
+type serverComponent string
+
+const (
+	smbdComponent        = serverComponent("smbd")
+	winbindComponent     = serverComponent("winbind")
+	ctdbComponent        = serverComponent("ctdb")
+	dnsRegisterComponent = serverComponent("dns-register")
+	svcWatchComponent    = serverComponent("svc-watch")
+	initComponent        = serverComponent("init")
+	metricsComponent     = serverComponent("metrics")
+	auditLogComponent    = serverComponent("audit-log")
+)
+
 type userSecuritySource struct {
-	Configured bool
-	Namespace  string
-	Secret     string
-	Key        string
+	Configured         bool
+	Namespace          string
+	Secret             string
+	Key                string
+	PlaintextPasswords plaintextPasswords
 }
 
 // InstanceConfiguration bundles together the various inputs that define
@@ -72,6 +114,11 @@ type sharePlanner struct {
 	// for managing the behavior of samba containers. The planner treats
 	// it as read/write.
 	ConfigState *smbcc.SambaContainerConfig
+
+	// passwordRotation is the digest of the password rotations of the
+	// users, as found on the users secret. It is annotated on the pod
+	// template so that rotating a password rolls the pods.
+	passwordRotation string
 }
 
 func newSharePlanner(
@@ -89,6 +136,46 @@ func (sp *sharePlanner) instanceName() string {
 	return sp.SmbShare.Status.ServerGroup
 }
 
+// centralized returns true if the resources hosting the share are created
+// in the operator's working namespace rather than the share's namespace.
+func (sp *sharePlanner) centralized() bool {
+	return sp.GlobalConfig != nil &&
+		sp.GlobalConfig.ServerNamespaceMode == conf.ServerNamespaceWorking &&
+		sp.GlobalConfig.WorkingNamespace != sp.SmbShare.Namespace
+}
+
+// podSecretName returns the name of the secret mounted in the pods for a
+// secret referenced by the share's configuration. Pods can only mount
+// secrets of their own namespace, so when the pods are not in the share's
+// namespace the operator copies the secret and the copy is mounted.
+func (sp *sharePlanner) podSecretName(name string) string {
+	if !sp.centralized() {
+		return name
+	}
+	return sp.instanceName() + "-" + name
+}
+
+// referencedSecrets returns the names of the secrets, in the namespace of
+// the share, that are mounted in the pods. The users secret is not mounted;
+// the operator derives a secret of its own from it.
+func (sp *sharePlanner) referencedSecrets() []string {
+	var names []string
+	if sp.securityMode() == adMode && sp.SecurityConfig != nil {
+		for _, js := range sp.SecurityConfig.Spec.JoinSources {
+			if js.UserJoin != nil {
+				names = append(names, js.UserJoin.Secret)
+			}
+		}
+	}
+	return names
+}
+
+// usersSecretName returns the name of the secret, derived from the users
+// secret, that holds the users config mounted in the pods.
+func (sp *sharePlanner) usersSecretName() string {
+	return sp.instanceName() + "-smbusers"
+}
+
 func (sp *sharePlanner) instanceID() smbcc.Key {
 	return smbcc.Key(sp.instanceName())
 }
@@ -117,6 +204,15 @@ func (sp *sharePlanner) containerConfigPath() string {
 	return cpath
 }
 
+// containerConfigVersion returns the schema version the container configs
+// of the pods are stored in, the newest one supported by the images.
+func (sp *sharePlanner) containerConfigVersion() string {
+	if v := sp.GlobalConfig.SambaContainerConfigVersion; v != "" {
+		return v
+	}
+	return smbcc.OldestVersion
+}
+
 func (*sharePlanner) containerConfigDir() string {
 	return "/etc/container-config"
 }
@@ -196,6 +292,11 @@ func (sp *sharePlanner) userSecuritySource() userSecuritySource {
 	s.Namespace = sp.SecurityConfig.Namespace
 	s.Secret = sp.SecurityConfig.Spec.Users.Secret
 	s.Key = sp.SecurityConfig.Spec.Users.Key
+	s.PlaintextPasswords = plaintextPasswords(
+		sp.SecurityConfig.Spec.Users.PlaintextPasswords)
+	if s.PlaintextPasswords != rejectPlaintextPasswords {
+		s.PlaintextPasswords = convertPlaintextPasswords
+	}
 	return s
 }
 
@@ -241,6 +342,10 @@ func (sp *sharePlanner) idmapOptions() smbcc.SmbOptions {
 }
 
 func (sp *sharePlanner) update() (changed bool, err error) {
+	if v := sp.containerConfigVersion(); sp.ConfigState.SCCVersion != v {
+		sp.ConfigState.SCCVersion = v
+		changed = true
+	}
 	noprinting, found := sp.ConfigState.Globals[smbcc.NoPrintingKey]
 	if !found {
 		noprinting = smbcc.NewNoPrintingGlobals()
@@ -260,6 +365,12 @@ func (sp *sharePlanner) update() (changed bool, err error) {
 		sp.ConfigState.Shares[shareKey] = share
 		changed = true
 	}
+	if sp.updateAuditOptions(share.Options) {
+		changed = true
+	}
+	if sp.updateAccessOptions(share.Options) {
+		changed = true
+	}
 	cfgKey := sp.instanceID()
 	cfg, found := sp.ConfigState.Configs[cfgKey]
 	if !found || cfg.Shares[0] != shareKey {
@@ -374,6 +485,105 @@ func (sp *sharePlanner) runDaemonArgs(name string) []string {
 	return args
 }
 
+// terminationGracePeriod returns the number of seconds the pods are given to
+// shut down.
+func (sp *sharePlanner) terminationGracePeriod() int64 {
+	common, share := sp.podSettings()
+	for _, ps := range []*sambaoperatorv1alpha1.SmbPodSettingsSpec{share, common} {
+		if ps != nil && ps.TerminationGracePeriodSeconds != nil {
+			return *ps.TerminationGracePeriodSeconds
+		}
+	}
+	return defaultTerminationGracePeriod
+}
+
+// drainSeconds returns the number of seconds smbd is given to wait for
+// clients to close their open files. Some of the grace period is reserved
+// for the processes to exit once the drain is complete.
+func (sp *sharePlanner) drainSeconds() int64 {
+	d := sp.terminationGracePeriod() - drainExitMargin
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// smbdPreStopCmd returns the command run before smbd is stopped. New
+// sessions are denied before the drain starts: Kubernetes removes a
+// terminating pod from the endpoints of its Service, and clustered nodes are
+// also disabled in ctdb. Then, until the drain deadline, the command waits
+// for open files to be closed. Finally all the shares of the server are
+// closed, which disconnects any remaining clients cleanly instead of
+// resetting their connections. Clients are not notified before the shares
+// are closed; without ctdb public addresses or a witness service there is
+// no way to ask them to move.
+func (sp *sharePlanner) smbdPreStopCmd() []string {
+	var script strings.Builder
+	fmt.Fprintf(&script,
+		"deadline=$(( $(date +%%s) + %d ));\n", sp.drainSeconds())
+	if sp.isClustered() {
+		script.WriteString("ctdb disable || true;\n")
+	}
+	script.WriteString(
+		"while [ \"$(date +%s)\" -lt \"$deadline\" ]; do\n" +
+			"  smbstatus --locks 2>/dev/null | grep -q 'No locked files' && break;\n" +
+			"  sleep 2;\n" +
+			"done;\n")
+	// a server group may host more shares than the share of the planner
+	script.WriteString("smbcontrol smbd close-share '*' || true\n")
+	return []string{"/bin/sh", "-c", script.String()}
+}
+
+// waitForSmbdCmd returns the command run before the daemons smbd depends on
+// are stopped. It waits for smbd to exit so that the daemons remain
+// available while smbd is draining. The wait lasts a little longer than the
+// drain so that smbd has time to exit after the drain deadline.
+func (sp *sharePlanner) waitForSmbdCmd() []string {
+	script := fmt.Sprintf(
+		"deadline=$(( $(date +%%s) + %d ));\n"+
+			"while [ \"$(date +%%s)\" -lt \"$deadline\" ]; do\n"+
+			"  pgrep -x smbd >/dev/null || break;\n"+
+			"  sleep 1;\n"+
+			"done\n",
+		sp.drainSeconds()+drainExitMargin/2)
+	return []string{"/bin/sh", "-c", script}
+}
+
+// smbdCheckCmd returns the command used to verify that smbd responds to
+// an SMB negotiate request. smbclient negotiates a protocol before trying
+// an anonymous session, so a refused session still shows that smbd is
+// serving clients. Failures to connect or to negotiate fail the check.
+func (*sharePlanner) smbdCheckCmd() []string {
+	script := "out=$(smbclient -N -L 127.0.0.1 2>&1) && exit 0;\n" +
+		"case \"$out\" in\n" +
+		"  *NT_STATUS_ACCESS_DENIED*|*NT_STATUS_LOGON_FAILURE*) exit 0;;\n" +
+		"esac;\n" +
+		"echo \"$out\" >&2;\n" +
+		"exit 1\n"
+	return []string{"/bin/sh", "-c", script}
+}
+
+// winbindCheckCmd returns the command used to verify that winbindd is
+// responding.
+func (*sharePlanner) winbindCheckCmd() []string {
+	return []string{"samba-container", "check", "winbind"}
+}
+
+// ctdbCheckCmd returns the command used to verify that the local ctdb node
+// is healthy. ctdb nodestatus exits with a non-zero status unless the node
+// is OK.
+func (*sharePlanner) ctdbCheckCmd() []string {
+	return []string{"ctdb", "nodestatus"}
+}
+
+// ctdbStartedCmd returns the command used to verify that ctdbd is running
+// and answers requests. Unlike ctdbCheckCmd it succeeds while the node is
+// banned, disabled or recovering, so a slow recovery does not use up the
+// startup budget of the container.
+func (*sharePlanner) ctdbStartedCmd() []string {
+	return []string{"ctdb", "pnn"}
+}
+
 func (*sharePlanner) ctdbDaemonArgs() []string {
 	return []string{
 		"run",
@@ -423,12 +633,176 @@ func (sp *sharePlanner) serviceType() string {
 	return "ClusterIP"
 }
 
+// containerImage returns the image of the containers of the given
+// component. The samba image annotation on the share replaces the images
+// of all samba containers, which allows trying a new samba build with a
+// single share.
+func (sp *sharePlanner) containerImage(c serverComponent) string {
+	gc := sp.GlobalConfig
+	switch c {
+	case svcWatchComponent:
+		return gc.SvcWatchContainerImage
+	case metricsComponent, auditLogComponent:
+		// the smbaudit tool is part of the metrics exporter image
+		return gc.MetricsContainerImage
+	}
+	if sp.SmbShare != nil {
+		if img := sp.SmbShare.Annotations[sambaImageAnnotation]; img != "" {
+			return img
+		}
+	}
+	var img string
+	switch c {
+	case winbindComponent:
+		img = gc.WinbindContainerImage
+	case ctdbComponent:
+		img = gc.CTDBContainerImage
+	case dnsRegisterComponent:
+		img = gc.DNSRegisterContainerImage
+	case initComponent:
+		img = gc.InitContainerImage
+	}
+	if img == "" {
+		img = gc.SmbdContainerImage
+	}
+	return img
+}
+
+func (sp *sharePlanner) imagePullPolicy() corev1.PullPolicy {
+	return sp.GlobalConfig.ImagePullPolicy
+}
+
+func (sp *sharePlanner) imagePullSecrets() []corev1.LocalObjectReference {
+	var secrets []corev1.LocalObjectReference
+	for _, name := range sp.GlobalConfig.ImagePullSecrets {
+		if name = strings.TrimSpace(name); name != "" {
+			secrets = append(secrets, corev1.LocalObjectReference{Name: name})
+		}
+	}
+	return secrets
+}
+
 func (sp *sharePlanner) sambaContainerDebugLevel() string {
 	return sp.GlobalConfig.SambaDebugLevel
 }
 
+// sambaDebugLevel returns the debug level for the daemon of the given
+// component. Values from the share take precedence over the common config,
+// which take precedence over the operator's default debug level. ctdbd does
+// not support debug classes, so it only inherits the level of the default.
+func (sp *sharePlanner) sambaDebugLevel(c serverComponent) string {
+	common, share := sp.podSettings()
+	for _, ps := range []*sambaoperatorv1alpha1.SmbPodSettingsSpec{share, common} {
+		if ps == nil || ps.DebugLevels == nil {
+			continue
+		}
+		var lvl string
+		switch c {
+		case smbdComponent:
+			lvl = ps.DebugLevels.Smbd
+		case winbindComponent:
+			lvl = ps.DebugLevels.Winbindd
+		case ctdbComponent:
+			lvl = ps.DebugLevels.Ctdbd
+		}
+		if lvl = strings.TrimSpace(lvl); lvl != "" {
+			return lvl
+		}
+	}
+	if c == ctdbComponent {
+		return leadingDebugLevel(sp.sambaContainerDebugLevel())
+	}
+	return sp.sambaContainerDebugLevel()
+}
+
+// leadingDebugLevel returns the level of a samba debug level without the
+// levels of the debug classes, e.g. "1" for "1 auth:5". It returns an empty
+// string if only classes are given.
+func leadingDebugLevel(lvl string) string {
+	fields := strings.Fields(lvl)
+	if len(fields) == 0 || strings.Contains(fields[0], ":") {
+		return ""
+	}
+	return fields[0]
+}
+
+func (sp *sharePlanner) metricsEnabled() bool {
+	return sp.GlobalConfig.MetricsExporterMode == conf.MetricsExporterEnabled
+}
+
+func (sp *sharePlanner) metricsExporterArgs() []string {
+	args := []string{fmt.Sprintf("--listen=:%d", metricsPort)}
+	if sp.isClustered() {
+		args = append(args, "--clustered")
+	}
+	return args
+}
+
 func (sp *sharePlanner) mayCluster() bool {
-	return sp.GlobalConfig.ClusterSupport == "ctdb-is-experimental"
+	return sp.GlobalConfig.ClusterSupport == conf.ClusterSupportCTDBExperimental
+}
+
+// statePVCSpec returns the spec of the PVC holding the shared state of a
+// clustered share. Values from the share take precedence over the
+// operator's defaults. The PVC is used by all instances of the share, so
+// an error is returned if it would not be ReadWriteMany. The PVC is
+// mounted as a filesystem, so an error is returned for a block volume.
+func (sp *sharePlanner) statePVCSpec() (
+	*corev1.PersistentVolumeClaimSpec, error) {
+	// ---
+	gc := sp.GlobalConfig
+	storageClass := gc.StatePVCStorageClass
+	accessModes := gc.StatePVCAccessModes
+	volumeMode := gc.StatePVCVolumeMode
+	if sp.SmbShare.Spec.Scaling != nil &&
+		sp.SmbShare.Spec.Scaling.StatePVC != nil {
+		// ---
+		s := sp.SmbShare.Spec.Scaling.StatePVC
+		if s.StorageClassName != "" {
+			storageClass = s.StorageClassName
+		}
+		if len(s.AccessModes) > 0 {
+			accessModes = s.AccessModes
+		}
+		if s.VolumeMode != nil {
+			volumeMode = *s.VolumeMode
+		}
+	}
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{
+			corev1.ReadWriteMany,
+		}
+	}
+	rwx := false
+	for _, m := range accessModes {
+		rwx = rwx || m == corev1.ReadWriteMany
+	}
+	if !rwx {
+		return nil, fmt.Errorf(
+			"access modes of the state PVC %v do not include %s",
+			accessModes, corev1.ReadWriteMany)
+	}
+	if volumeMode == corev1.PersistentVolumeBlock {
+		return nil, fmt.Errorf(
+			"volume mode of the state PVC must not be %s", volumeMode)
+	}
+
+	spec := &corev1.PersistentVolumeClaimSpec{
+		AccessModes: append(
+			[]corev1.PersistentVolumeAccessMode(nil), accessModes...),
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceStorage: gc.StatePVCSize.DeepCopy(),
+			},
+		},
+	}
+	if storageClass != "" {
+		spec.StorageClassName = &storageClass
+	}
+	if volumeMode != "" {
+		spec.VolumeMode = &volumeMode
+	}
+	return spec, nil
 }
 
 func (sp *sharePlanner) isClustered() bool {
@@ -446,7 +820,279 @@ func (sp *sharePlanner) clusterSize() int32 {
 }
 
 // nodeSpread returns true if pods are required to be spread over multiple
-// nodes.
+// nodes. The nodeSpread field takes precedence over the legacy annotation.
 func (sp *sharePlanner) nodeSpread() bool {
+	scaling := sp.SmbShare.Spec.Scaling
+	if scaling != nil && scaling.NodeSpread != nil &&
+		scaling.NodeSpread.Enabled != nil {
+		return *scaling.NodeSpread.Enabled
+	}
 	return sp.SmbShare.Annotations[nodeSpreadKey] != nodeSpreadDisable
 }
+
+// nodeSpreadTopologyKey returns the node label that defines the topology
+// domains pods are spread across.
+func (sp *sharePlanner) nodeSpreadTopologyKey() string {
+	scaling := sp.SmbShare.Spec.Scaling
+	if scaling == nil || scaling.NodeSpread == nil {
+		return defaultTopologyKey
+	}
+	if scaling.NodeSpread.TopologyKey == "" {
+		return defaultTopologyKey
+	}
+	return scaling.NodeSpread.TopologyKey
+}
+
+// podSettings returns the pod settings from the common config and from the
+// share. Either value may be nil.
+func (sp *sharePlanner) podSettings() (
+	common, share *sambaoperatorv1alpha1.SmbPodSettingsSpec) {
+	// ---
+	if sp.CommonConfig != nil {
+		common = sp.CommonConfig.Spec.PodSettings
+	}
+	if sp.SmbShare != nil {
+		share = sp.SmbShare.Spec.PodSettings
+	}
+	return common, share
+}
+
+// extraContainers returns the user defined containers of the common config
+// followed by those of the share.
+func (sp *sharePlanner) extraContainers() []corev1.Container {
+	var ctrs []corev1.Container
+	common, share := sp.podSettings()
+	for _, ps := range []*sambaoperatorv1alpha1.SmbPodSettingsSpec{common, share} {
+		if ps == nil {
+			continue
+		}
+		for i := range ps.Containers {
+			ctrs = append(ctrs, *ps.Containers[i].DeepCopy())
+		}
+	}
+	return ctrs
+}
+
+// extraInitContainers returns the user defined init containers of the
+// common config followed by those of the share.
+func (sp *sharePlanner) extraInitContainers() []corev1.Container {
+	var ctrs []corev1.Container
+	common, share := sp.podSettings()
+	for _, ps := range []*sambaoperatorv1alpha1.SmbPodSettingsSpec{common, share} {
+		if ps == nil {
+			continue
+		}
+		for i := range ps.InitContainers {
+			ctrs = append(ctrs, *ps.InitContainers[i].DeepCopy())
+		}
+	}
+	return ctrs
+}
+
+// extraVolumes returns the user defined volumes of the common config
+// followed by those of the share.
+func (sp *sharePlanner) extraVolumes() []corev1.Volume {
+	var vols []corev1.Volume
+	common, share := sp.podSettings()
+	for _, ps := range []*sambaoperatorv1alpha1.SmbPodSettingsSpec{common, share} {
+		if ps == nil {
+			continue
+		}
+		for i := range ps.Volumes {
+			vols = append(vols, *ps.Volumes[i].DeepCopy())
+		}
+	}
+	return vols
+}
+
+func (sp *sharePlanner) nodeSelector() map[string]string {
+	common, share := sp.podSettings()
+	sel := map[string]string{}
+	// the share's selector is merged on top of the common selector
+	for _, ps := range []*sambaoperatorv1alpha1.SmbPodSettingsSpec{common, share} {
+		if ps == nil {
+			continue
+		}
+		for k, v := range ps.NodeSelector {
+			sel[k] = v
+		}
+	}
+	if len(sel) == 0 {
+		return nil
+	}
+	return sel
+}
+
+func (sp *sharePlanner) tolerations() []corev1.Toleration {
+	common, share := sp.podSettings()
+	if share != nil && len(share.Tolerations) > 0 {
+		return share.Tolerations
+	}
+	if common != nil {
+		return common.Tolerations
+	}
+	return nil
+}
+
+func (sp *sharePlanner) affinity() *corev1.Affinity {
+	common, share := sp.podSettings()
+	if share != nil && share.Affinity != nil {
+		return share.Affinity.DeepCopy()
+	}
+	if common != nil && common.Affinity != nil {
+		return common.Affinity.DeepCopy()
+	}
+	return nil
+}
+
+func (sp *sharePlanner) topologySpreadConstraints() []corev1.TopologySpreadConstraint {
+	common, share := sp.podSettings()
+	if share != nil && len(share.TopologySpreadConstraints) > 0 {
+		return share.TopologySpreadConstraints
+	}
+	if common != nil {
+		return common.TopologySpreadConstraints
+	}
+	return nil
+}
+
+func (sp *sharePlanner) priorityClassName() string {
+	common, share := sp.podSettings()
+	if share != nil && share.PriorityClassName != "" {
+		return share.PriorityClassName
+	}
+	if common != nil {
+		return common.PriorityClassName
+	}
+	return ""
+}
+
+// maxUnavailable returns the number of pods of the server group that may be
+// unavailable due to voluntary disruptions.
+func (sp *sharePlanner) maxUnavailable() intstr.IntOrString {
+	// a clustered instance needs to keep a majority of nodes around, only
+	// allow one node to be taken out at a time.
+	if sp.isClustered() {
+		return intstr.FromInt(1)
+	}
+	common, share := sp.podSettings()
+	for _, ps := range []*sambaoperatorv1alpha1.SmbPodSettingsSpec{share, common} {
+		if ps == nil || ps.DisruptionBudget == nil {
+			continue
+		}
+		if ps.DisruptionBudget.MaxUnavailable != nil {
+			return *ps.DisruptionBudget.MaxUnavailable
+		}
+	}
+	return intstr.FromInt(1)
+}
+
+// containerResources returns the compute resource requirements for the
+// containers of the given component. Values from the share take precedence
+// over the common config, which take precedence over the operator defaults.
+func (sp *sharePlanner) containerResources(
+	c serverComponent) corev1.ResourceRequirements {
+	// ---
+	common, share := sp.podSettings()
+	for _, ps := range []*sambaoperatorv1alpha1.SmbPodSettingsSpec{share, common} {
+		if ps == nil || ps.Resources == nil {
+			continue
+		}
+		if r := componentResources(ps.Resources, c); r != nil {
+			return *r.DeepCopy()
+		}
+	}
+	return sp.defaultContainerResources(c)
+}
+
+func componentResources(
+	r *sambaoperatorv1alpha1.SmbContainerResourcesSpec,
+	c serverComponent) *corev1.ResourceRequirements {
+	// ---
+	switch c {
+	case smbdComponent:
+		return r.Smbd
+	case winbindComponent:
+		return r.Winbind
+	case ctdbComponent:
+		return r.CTDB
+	case dnsRegisterComponent:
+		return r.DNSRegister
+	case svcWatchComponent:
+		return r.SvcWatch
+	case initComponent:
+		return r.Init
+	case metricsComponent:
+		return r.Metrics
+	}
+	return nil
+}
+
+func (sp *sharePlanner) defaultContainerResources(
+	c serverComponent) corev1.ResourceRequirements {
+	// ---
+	var requests, limits corev1.ResourceList
+	gc := sp.GlobalConfig
+	switch c {
+	case smbdComponent:
+		requests, limits = gc.SmbdResourceRequests, gc.SmbdResourceLimits
+	case winbindComponent:
+		requests, limits = gc.WinbindResourceRequests, gc.WinbindResourceLimits
+	case ctdbComponent:
+		requests, limits = gc.CTDBResourceRequests, gc.CTDBResourceLimits
+	case dnsRegisterComponent:
+		requests = gc.DNSRegisterResourceRequests
+		limits = gc.DNSRegisterResourceLimits
+	case svcWatchComponent:
+		requests, limits = gc.SvcWatchResourceRequests, gc.SvcWatchResourceLimits
+	case initComponent:
+		requests, limits = gc.InitResourceRequests, gc.InitResourceLimits
+	case metricsComponent:
+		requests, limits = gc.MetricsResourceRequests, gc.MetricsResourceLimits
+	}
+	// the lists are copied so that the containers never share the maps
+	// of the operator config.
+	return corev1.ResourceRequirements{
+		Requests: requests.DeepCopy(),
+		Limits:   limits.DeepCopy(),
+	}
+}
+
+// podSecurityContext returns the pod level security context for the pods of
+// the server group.
+func (sp *sharePlanner) podSecurityContext() *corev1.PodSecurityContext {
+	psc := &corev1.PodSecurityContext{
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+	common, share := sp.podSettings()
+	// the share's values are applied on top of the common values
+	for _, ps := range []*sambaoperatorv1alpha1.SmbPodSettingsSpec{common, share} {
+		if ps == nil || ps.SecurityContext == nil {
+			continue
+		}
+		sc := ps.SecurityContext.DeepCopy()
+		if sc.FSGroup != nil {
+			psc.FSGroup = sc.FSGroup
+		}
+		if sc.FSGroupChangePolicy != nil {
+			psc.FSGroupChangePolicy = sc.FSGroupChangePolicy
+		}
+		if sc.SeccompProfile != nil {
+			psc.SeccompProfile = sc.SeccompProfile
+		}
+	}
+	if psc.FSGroup != nil && psc.FSGroupChangePolicy == nil {
+		// avoid recursively changing the ownership of potentially
+		// very large shares every time a pod starts
+		onRootMismatch := corev1.FSGroupChangeOnRootMismatch
+		psc.FSGroupChangePolicy = &onRootMismatch
+	}
+	return psc
+}
+
+// shellQuote returns s quoted for use as a single word in a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
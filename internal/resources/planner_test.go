@@ -19,8 +19,13 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
 	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
 )
 
@@ -155,3 +160,902 @@ func TestPlannerDNSRegisterArgs(t *testing.T) {
 		},
 		v)
 }
+
+func TestPlannerPodScheduling(t *testing.T) {
+	var planner *sharePlanner
+
+	// no settings at all
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{},
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Nil(t, planner.nodeSelector())
+	assert.Nil(t, planner.tolerations())
+	assert.Nil(t, planner.affinity())
+	assert.Nil(t, planner.topologySpreadConstraints())
+	assert.Equal(t, "", planner.priorityClassName())
+
+	// common config settings only
+	common := &sambaoperatorv1alpha1.SmbCommonConfig{
+		Spec: sambaoperatorv1alpha1.SmbCommonConfigSpec{
+			PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+				NodeSelector: map[string]string{
+					"disktype": "ssd",
+					"zone":     "east",
+				},
+				Tolerations: []corev1.Toleration{{
+					Key:      "storage",
+					Operator: corev1.TolerationOpExists,
+				}},
+				PriorityClassName: "low",
+			},
+		},
+	}
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare:     &sambaoperatorv1alpha1.SmbShare{},
+			CommonConfig: common,
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Equal(t,
+		map[string]string{"disktype": "ssd", "zone": "east"},
+		planner.nodeSelector())
+	assert.Len(t, planner.tolerations(), 1)
+	assert.Equal(t, "low", planner.priorityClassName())
+
+	// share overrides common config
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{
+				Spec: sambaoperatorv1alpha1.SmbShareSpec{
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						NodeSelector: map[string]string{
+							"zone": "west",
+						},
+						PriorityClassName: "high",
+					},
+				},
+			},
+			CommonConfig: common,
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Equal(t,
+		map[string]string{"disktype": "ssd", "zone": "west"},
+		planner.nodeSelector())
+	assert.Len(t, planner.tolerations(), 1)
+	assert.Equal(t, "high", planner.priorityClassName())
+}
+
+func TestPlannerNodeSpread(t *testing.T) {
+	share := &sambaoperatorv1alpha1.SmbShare{}
+	planner := newSharePlanner(
+		InstanceConfiguration{SmbShare: share},
+		&smbcc.SambaContainerConfig{})
+
+	// defaults to spreading over nodes
+	assert.True(t, planner.nodeSpread())
+	assert.Equal(t, "kubernetes.io/hostname", planner.nodeSpreadTopologyKey())
+
+	// the legacy annotation still disables spreading
+	share.Annotations = map[string]string{nodeSpreadKey: "false"}
+	assert.False(t, planner.nodeSpread())
+
+	// a topology key alone does not change the enabled state
+	share.Spec.Scaling = &sambaoperatorv1alpha1.SmbShareScalingSpec{
+		NodeSpread: &sambaoperatorv1alpha1.SmbShareNodeSpreadSpec{
+			TopologyKey: "topology.kubernetes.io/zone",
+		},
+	}
+	assert.False(t, planner.nodeSpread())
+	share.Annotations = nil
+	assert.True(t, planner.nodeSpread())
+	assert.Equal(t,
+		"topology.kubernetes.io/zone", planner.nodeSpreadTopologyKey())
+
+	// the field takes precedence over the annotation
+	enabled := true
+	share.Annotations = map[string]string{nodeSpreadKey: "false"}
+	share.Spec.Scaling.NodeSpread.Enabled = &enabled
+	assert.True(t, planner.nodeSpread())
+	enabled = false
+	share.Annotations = nil
+	assert.False(t, planner.nodeSpread())
+}
+
+func TestPlannerContainerResources(t *testing.T) {
+	var (
+		planner *sharePlanner
+		r       corev1.ResourceRequirements
+	)
+	gconfig := &conf.OperatorConfig{
+		SmbdResourceRequests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+		},
+		InitResourceLimits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+	}
+
+	// operator defaults only
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare:     &sambaoperatorv1alpha1.SmbShare{},
+			GlobalConfig: gconfig,
+		},
+		&smbcc.SambaContainerConfig{})
+	r = planner.containerResources(smbdComponent)
+	assert.Len(t, r.Requests, 2)
+	assert.Nil(t, r.Limits)
+	r = planner.containerResources(initComponent)
+	assert.Nil(t, r.Requests)
+	assert.Len(t, r.Limits, 1)
+	r = planner.containerResources(winbindComponent)
+	assert.Nil(t, r.Requests)
+	assert.Nil(t, r.Limits)
+
+	// common config replaces the defaults of a component
+	common := &sambaoperatorv1alpha1.SmbCommonConfig{
+		Spec: sambaoperatorv1alpha1.SmbCommonConfigSpec{
+			PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+				Resources: &sambaoperatorv1alpha1.SmbContainerResourcesSpec{
+					Smbd: &corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare:     &sambaoperatorv1alpha1.SmbShare{},
+			CommonConfig: common,
+			GlobalConfig: gconfig,
+		},
+		&smbcc.SambaContainerConfig{})
+	r = planner.containerResources(smbdComponent)
+	assert.Nil(t, r.Requests)
+	assert.True(t,
+		r.Limits[corev1.ResourceMemory].Equal(resource.MustParse("1Gi")))
+	r = planner.containerResources(initComponent)
+	assert.Len(t, r.Limits, 1)
+
+	// share replaces the common config
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{
+				Spec: sambaoperatorv1alpha1.SmbShareSpec{
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						Resources: &sambaoperatorv1alpha1.SmbContainerResourcesSpec{
+							Smbd: &corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("2Gi"),
+								},
+							},
+						},
+					},
+				},
+			},
+			CommonConfig: common,
+			GlobalConfig: gconfig,
+		},
+		&smbcc.SambaContainerConfig{})
+	r = planner.containerResources(smbdComponent)
+	assert.True(t,
+		r.Limits[corev1.ResourceMemory].Equal(resource.MustParse("2Gi")))
+}
+
+func TestPlannerMaxUnavailable(t *testing.T) {
+	var planner *sharePlanner
+	two := intstr.FromInt(2)
+	zero := intstr.FromInt(0)
+	common := &sambaoperatorv1alpha1.SmbCommonConfig{
+		Spec: sambaoperatorv1alpha1.SmbCommonConfigSpec{
+			PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+				DisruptionBudget: &sambaoperatorv1alpha1.SmbDisruptionBudgetSpec{
+					MaxUnavailable: &zero,
+				},
+			},
+		},
+	}
+
+	// default
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{},
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Equal(t, intstr.FromInt(1), planner.maxUnavailable())
+
+	// standard share uses the common config
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare:     &sambaoperatorv1alpha1.SmbShare{},
+			CommonConfig: common,
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Equal(t, zero, planner.maxUnavailable())
+
+	// clustered share ignores configured values
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{
+				Spec: sambaoperatorv1alpha1.SmbShareSpec{
+					Scaling: &sambaoperatorv1alpha1.SmbShareScalingSpec{
+						AvailbilityMode: "clustered",
+						MinClusterSize:  3,
+					},
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						DisruptionBudget: &sambaoperatorv1alpha1.SmbDisruptionBudgetSpec{
+							MaxUnavailable: &two,
+						},
+					},
+				},
+			},
+			CommonConfig: common,
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Equal(t, intstr.FromInt(1), planner.maxUnavailable())
+}
+
+func TestPlannerPodSecurityContext(t *testing.T) {
+	var (
+		planner *sharePlanner
+		psc     *corev1.PodSecurityContext
+	)
+
+	// defaults
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{},
+		},
+		&smbcc.SambaContainerConfig{})
+	psc = planner.podSecurityContext()
+	assert.Equal(t,
+		corev1.SeccompProfileTypeRuntimeDefault, psc.SeccompProfile.Type)
+	assert.Nil(t, psc.FSGroup)
+	assert.Nil(t, psc.FSGroupChangePolicy)
+
+	// fsGroup from common config, seccomp from share
+	gid := int64(2000)
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{
+				Spec: sambaoperatorv1alpha1.SmbShareSpec{
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						SecurityContext: &sambaoperatorv1alpha1.SmbPodSecuritySpec{
+							SeccompProfile: &corev1.SeccompProfile{
+								Type: corev1.SeccompProfileTypeUnconfined,
+							},
+						},
+					},
+				},
+			},
+			CommonConfig: &sambaoperatorv1alpha1.SmbCommonConfig{
+				Spec: sambaoperatorv1alpha1.SmbCommonConfigSpec{
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						SecurityContext: &sambaoperatorv1alpha1.SmbPodSecuritySpec{
+							FSGroup: &gid,
+						},
+					},
+				},
+			},
+		},
+		&smbcc.SambaContainerConfig{})
+	psc = planner.podSecurityContext()
+	assert.Equal(t,
+		corev1.SeccompProfileTypeUnconfined, psc.SeccompProfile.Type)
+	assert.Equal(t, gid, *psc.FSGroup)
+	assert.Equal(t,
+		corev1.FSGroupChangeOnRootMismatch, *psc.FSGroupChangePolicy)
+}
+
+func TestPlannerProbeTiming(t *testing.T) {
+	var planner *sharePlanner
+	period := int32(30)
+	failures := int32(60)
+
+	// defaults
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{},
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Equal(t, int32(10), planner.probeTiming(readinessProbe).periodSeconds)
+	assert.Equal(t, int32(3), planner.probeTiming(livenessProbe).failureThreshold)
+	assert.Equal(t, int32(30), planner.probeTiming(startupProbe).failureThreshold)
+
+	// common config and share values are combined
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{
+				Spec: sambaoperatorv1alpha1.SmbShareSpec{
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						Probes: &sambaoperatorv1alpha1.SmbProbesSpec{
+							Startup: &sambaoperatorv1alpha1.SmbProbeTimingSpec{
+								FailureThreshold: &failures,
+							},
+						},
+					},
+				},
+			},
+			CommonConfig: &sambaoperatorv1alpha1.SmbCommonConfig{
+				Spec: sambaoperatorv1alpha1.SmbCommonConfigSpec{
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						Probes: &sambaoperatorv1alpha1.SmbProbesSpec{
+							Startup: &sambaoperatorv1alpha1.SmbProbeTimingSpec{
+								PeriodSeconds: &period,
+							},
+						},
+					},
+				},
+			},
+			GlobalConfig: &conf.OperatorConfig{},
+		},
+		&smbcc.SambaContainerConfig{})
+	st := planner.probeTiming(startupProbe)
+	assert.Equal(t, period, st.periodSeconds)
+	assert.Equal(t, failures, st.failureThreshold)
+	assert.Equal(t, int32(5), st.timeoutSeconds)
+	assert.Equal(t, int32(10), planner.probeTiming(readinessProbe).periodSeconds)
+
+	p := buildExecProbe(planner, startupProbe, planner.ctdbCheckCmd())
+	assert.Equal(t,
+		[]string{"ctdb", "nodestatus"},
+		p.Exec.Command)
+	assert.Equal(t, failures, p.FailureThreshold)
+
+	ctr := buildSmbdCtr(planner, nil, nil)
+	assert.Nil(t, ctr.ReadinessProbe.TCPSocket)
+	assert.Equal(t, planner.smbdCheckCmd(), ctr.ReadinessProbe.Exec.Command)
+	assert.Equal(t, planner.smbdCheckCmd(), ctr.LivenessProbe.Exec.Command)
+	assert.Equal(t, planner.smbdCheckCmd(), ctr.StartupProbe.Exec.Command)
+	assert.Contains(t,
+		ctr.ReadinessProbe.Exec.Command[2], "smbclient -N -L 127.0.0.1")
+	ctr = buildCTDBDaemonCtr(planner, nil, nil)
+	assert.Nil(t, ctr.LivenessProbe)
+	assert.Equal(t,
+		[]string{"ctdb", "nodestatus"},
+		ctr.ReadinessProbe.Exec.Command)
+	assert.Equal(t,
+		[]string{"ctdb", "pnn"},
+		ctr.StartupProbe.Exec.Command)
+	ctr = buildWinbinddCtr(planner, nil, nil)
+	assert.Equal(t,
+		[]string{"samba-container", "check", "winbind"},
+		ctr.LivenessProbe.Exec.Command)
+}
+
+func TestPlannerGracefulShutdown(t *testing.T) {
+	var (
+		planner *sharePlanner
+		cmd     []string
+	)
+	grace := int64(300)
+
+	// defaults, standard share
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{
+				Spec: sambaoperatorv1alpha1.SmbShareSpec{
+					ShareName: "Bob's Files",
+				},
+			},
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Equal(t, int64(60), planner.terminationGracePeriod())
+	assert.Equal(t, int64(50), planner.drainSeconds())
+	cmd = planner.smbdPreStopCmd()
+	assert.Len(t, cmd, 3)
+	assert.Contains(t, cmd[2], "+ 50 ))")
+	assert.NotContains(t, cmd[2], "ctdb disable")
+	assert.Contains(t, cmd[2], `close-share '*'`)
+	assert.NotContains(t, cmd[2], "Bob")
+	assert.Contains(t, planner.waitForSmbdCmd()[2], "+ 55 ))")
+
+	// clustered share with a custom grace period
+	planner = newSharePlanner(
+		InstanceConfiguration{
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{
+				Spec: sambaoperatorv1alpha1.SmbShareSpec{
+					Scaling: &sambaoperatorv1alpha1.SmbShareScalingSpec{
+						AvailbilityMode: "clustered",
+						MinClusterSize:  3,
+					},
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						TerminationGracePeriodSeconds: &grace,
+					},
+				},
+			},
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Equal(t, grace, planner.terminationGracePeriod())
+	cmd = planner.smbdPreStopCmd()
+	assert.Contains(t, cmd[2], "+ 290 ))")
+	assert.Contains(t, cmd[2], "ctdb disable")
+}
+
+func TestPlannerDebugLevels(t *testing.T) {
+	gconfig := &conf.OperatorConfig{
+		SmbdContainerName:    "samba",
+		WinbindContainerName: "wb",
+		SambaDebugLevel:      "2",
+	}
+	planner := newSharePlanner(
+		InstanceConfiguration{
+			GlobalConfig: gconfig,
+			SmbShare: &sambaoperatorv1alpha1.SmbShare{
+				Spec: sambaoperatorv1alpha1.SmbShareSpec{
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						DebugLevels: &sambaoperatorv1alpha1.SmbDebugLevelsSpec{
+							Smbd: "1 auth:5 vfs:3",
+						},
+					},
+				},
+			},
+			CommonConfig: &sambaoperatorv1alpha1.SmbCommonConfig{
+				Spec: sambaoperatorv1alpha1.SmbCommonConfigSpec{
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						DebugLevels: &sambaoperatorv1alpha1.SmbDebugLevelsSpec{
+							Smbd:     "4",
+							Winbindd: "3",
+						},
+					},
+				},
+			},
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Equal(t, "1 auth:5 vfs:3", planner.sambaDebugLevel(smbdComponent))
+	assert.Equal(t, "3", planner.sambaDebugLevel(winbindComponent))
+	assert.Equal(t, "2", planner.sambaDebugLevel(ctdbComponent))
+	assert.Equal(t, "2", planner.sambaDebugLevel(initComponent))
+
+	// ctdbd only gets the level of a default with debug classes
+	gconfig.SambaDebugLevel = "1 auth:5"
+	assert.Equal(t, "1", planner.sambaDebugLevel(ctdbComponent))
+	assert.Equal(t, "1 auth:5", planner.sambaDebugLevel(initComponent))
+	gconfig.SambaDebugLevel = "auth:5"
+	assert.Equal(t, "", planner.sambaDebugLevel(ctdbComponent))
+	gconfig.SambaDebugLevel = "2"
+
+	tmpl := &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "samba",
+					Env: []corev1.EnvVar{
+						{Name: "SAMBACC_CONFIG", Value: "/etc/x"},
+						{Name: "SAMBA_DEBUG_LEVEL", Value: "2"},
+					},
+				},
+				{
+					Name: "wb",
+					Env: []corev1.EnvVar{
+						{Name: "SAMBA_DEBUG_LEVEL", Value: "3"},
+					},
+				},
+				{
+					Name: "svc-watch",
+					Env: []corev1.EnvVar{
+						{Name: "SAMBA_DEBUG_LEVEL", Value: "2"},
+					},
+				},
+			},
+		},
+	}
+	assert.True(t, syncDebugLevels(planner, tmpl))
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "SAMBACC_CONFIG", Value: "/etc/x"},
+		{Name: "SAMBA_DEBUG_LEVEL", Value: "1 auth:5 vfs:3"},
+	}, tmpl.Spec.Containers[0].Env)
+	assert.Equal(t, "3", tmpl.Spec.Containers[1].Env[0].Value)
+	assert.Equal(t, "2", tmpl.Spec.Containers[2].Env[0].Value)
+	assert.False(t, syncDebugLevels(planner, tmpl))
+}
+
+func TestPlannerContainerImages(t *testing.T) {
+	gconfig := &conf.OperatorConfig{
+		SmbdContainerImage:     "samba:1",
+		SvcWatchContainerImage: "svcwatch:1",
+		CTDBContainerImage:     "ctdb:1",
+		ImagePullPolicy:        "IfNotPresent",
+		ImagePullSecrets:       []string{"regcred", " other", ""},
+	}
+	share := &sambaoperatorv1alpha1.SmbShare{}
+	planner := newSharePlanner(
+		InstanceConfiguration{
+			GlobalConfig: gconfig,
+			SmbShare:     share,
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.Equal(t, "samba:1", planner.containerImage(smbdComponent))
+	assert.Equal(t, "samba:1", planner.containerImage(winbindComponent))
+	assert.Equal(t, "ctdb:1", planner.containerImage(ctdbComponent))
+	assert.Equal(t, "samba:1", planner.containerImage(initComponent))
+	assert.Equal(t, "svcwatch:1", planner.containerImage(svcWatchComponent))
+	assert.Equal(t, corev1.PullIfNotPresent, planner.imagePullPolicy())
+	assert.Equal(t,
+		[]corev1.LocalObjectReference{{Name: "regcred"}, {Name: "other"}},
+		planner.imagePullSecrets())
+
+	share.Annotations = map[string]string{
+		"samba-operator.samba.org/samba-image": "samba:canary",
+	}
+	assert.Equal(t, "samba:canary", planner.containerImage(smbdComponent))
+	assert.Equal(t, "samba:canary", planner.containerImage(ctdbComponent))
+	assert.Equal(t, "svcwatch:1", planner.containerImage(svcWatchComponent))
+}
+
+func TestSyncContainers(t *testing.T) {
+	gconfig := &conf.OperatorConfig{
+		SmbdContainerName:      "samba",
+		SmbdContainerImage:     "samba:2",
+		SvcWatchContainerImage: "svcwatch:2",
+	}
+	share := &sambaoperatorv1alpha1.SmbShare{}
+	planner := newSharePlanner(
+		InstanceConfiguration{
+			GlobalConfig: gconfig,
+			SmbShare:     share,
+		},
+		&smbcc.SambaContainerConfig{})
+	tmpl := &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "init", Image: "samba:1"},
+			},
+			Containers: []corev1.Container{
+				{Name: "samba", Image: "samba:1"},
+				{Name: "svc-watch", Image: "svcwatch:1"},
+				{Name: "exporter", Image: "exporter:1"},
+			},
+		},
+	}
+	assert.True(t, syncContainers(planner, tmpl))
+	assert.Equal(t, "samba:2", tmpl.Spec.InitContainers[0].Image)
+	assert.Equal(t, "samba:2", tmpl.Spec.Containers[0].Image)
+	assert.Equal(t, "svcwatch:2", tmpl.Spec.Containers[1].Image)
+	assert.Equal(t, "exporter:1", tmpl.Spec.Containers[2].Image)
+	assert.False(t, syncContainers(planner, tmpl))
+
+	share.Annotations = map[string]string{
+		"samba-operator.samba.org/samba-image": "samba:canary",
+	}
+	assert.True(t, syncContainers(planner, tmpl))
+	assert.Equal(t, "samba:canary", tmpl.Spec.InitContainers[0].Image)
+	assert.Equal(t, "samba:canary", tmpl.Spec.Containers[0].Image)
+	assert.Equal(t, "svcwatch:2", tmpl.Spec.Containers[1].Image)
+
+	gconfig.ImagePullPolicy = corev1.PullAlways
+	gconfig.SmbdResourceLimits = corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	assert.True(t, syncContainers(planner, tmpl))
+	assert.Equal(t, corev1.PullAlways, tmpl.Spec.Containers[0].ImagePullPolicy)
+	assert.Equal(t, corev1.PullPolicy(""), tmpl.Spec.Containers[2].ImagePullPolicy)
+	mem := tmpl.Spec.Containers[0].Resources.Requests[corev1.ResourceMemory]
+	assert.Equal(t, "1Gi", mem.String())
+	assert.Nil(t, tmpl.Spec.Containers[1].Resources.Limits)
+
+	// quantities stored in another form are not updated again
+	tmpl.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory] =
+		resource.MustParse("1024Mi")
+	assert.False(t, syncContainers(planner, tmpl))
+
+	gconfig.SmbdResourceLimits = nil
+	assert.True(t, syncContainers(planner, tmpl))
+	assert.Nil(t, tmpl.Spec.Containers[0].Resources.Limits)
+	assert.Nil(t, tmpl.Spec.Containers[0].Resources.Requests)
+}
+
+func TestPlannerPodExtras(t *testing.T) {
+	gconfig := &conf.OperatorConfig{
+		SmbdContainerName:    "samba",
+		WinbindContainerName: "wb",
+	}
+	share := &sambaoperatorv1alpha1.SmbShare{
+		Spec: sambaoperatorv1alpha1.SmbShareSpec{
+			Storage: sambaoperatorv1alpha1.SmbShareStorageSpec{
+				Pvc: &sambaoperatorv1alpha1.SmbSharePvcSpec{Name: "data"},
+			},
+			PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+				Containers: []corev1.Container{{Name: "exporter"}},
+			},
+		},
+	}
+	planner := newSharePlanner(
+		InstanceConfiguration{
+			GlobalConfig: gconfig,
+			SmbShare:     share,
+			CommonConfig: &sambaoperatorv1alpha1.SmbCommonConfig{
+				Spec: sambaoperatorv1alpha1.SmbCommonConfigSpec{
+					PodSettings: &sambaoperatorv1alpha1.SmbPodSettingsSpec{
+						Containers: []corev1.Container{{Name: "shipper"}},
+						InitContainers: []corev1.Container{
+							{Name: "prestart"},
+						},
+						Volumes: []corev1.Volume{{Name: "logs"}},
+					},
+				},
+			},
+		},
+		&smbcc.SambaContainerConfig{})
+	podSpec := buildPodSpec(planner, gconfig, "data")
+	assert.NoError(t, checkPodNames(&podSpec))
+	n := len(podSpec.Containers)
+	assert.Equal(t, "shipper", podSpec.Containers[n-2].Name)
+	assert.Equal(t, "exporter", podSpec.Containers[n-1].Name)
+	n = len(podSpec.InitContainers)
+	assert.Equal(t, "prestart", podSpec.InitContainers[n-1].Name)
+	n = len(podSpec.Volumes)
+	assert.Equal(t, "logs", podSpec.Volumes[n-1].Name)
+
+	share.Spec.PodSettings.Containers[0].Name = "samba"
+	podSpec = buildPodSpec(planner, gconfig, "data")
+	assert.Error(t, checkPodNames(&podSpec))
+
+	share.Spec.PodSettings.Containers[0].Name = "prestart"
+	podSpec = buildPodSpec(planner, gconfig, "data")
+	assert.Error(t, checkPodNames(&podSpec))
+
+	share.Spec.PodSettings.Containers[0].Name = "exporter"
+	share.Spec.PodSettings.Volumes = []corev1.Volume{{Name: "logs"}}
+	podSpec = buildPodSpec(planner, gconfig, "data")
+	assert.Error(t, checkPodNames(&podSpec))
+}
+
+func TestPlannerMetricsExporter(t *testing.T) {
+	gconfig := &conf.OperatorConfig{
+		SmbdContainerName:     "samba",
+		MetricsContainerImage: "metrics:1",
+	}
+	share := &sambaoperatorv1alpha1.SmbShare{
+		Spec: sambaoperatorv1alpha1.SmbShareSpec{
+			Storage: sambaoperatorv1alpha1.SmbShareStorageSpec{
+				Pvc: &sambaoperatorv1alpha1.SmbSharePvcSpec{Name: "data"},
+			},
+		},
+	}
+	planner := newSharePlanner(
+		InstanceConfiguration{
+			GlobalConfig: gconfig,
+			SmbShare:     share,
+		},
+		&smbcc.SambaContainerConfig{})
+	assert.False(t, planner.metricsEnabled())
+	podSpec := buildPodSpec(planner, gconfig, "data")
+	assert.Len(t, podSpec.Containers, 1)
+
+	gconfig.MetricsExporterMode = "enabled"
+	assert.True(t, planner.metricsEnabled())
+	assert.Equal(t, []string{"--listen=:8080"}, planner.metricsExporterArgs())
+	podSpec = buildPodSpec(planner, gconfig, "data")
+	assert.NoError(t, checkPodNames(&podSpec))
+	if assert.Len(t, podSpec.Containers, 2) {
+		ctr := podSpec.Containers[1]
+		assert.Equal(t, "smbmetrics", ctr.Name)
+		assert.Equal(t, "metrics:1", ctr.Image)
+		assert.Equal(t, int32(8080), ctr.Ports[0].ContainerPort)
+		// the exporter and smbd share the samba state
+		assert.Equal(t,
+			podSpec.Containers[0].VolumeMounts, ctr.VolumeMounts)
+	}
+
+	share.Spec.Scaling = &sambaoperatorv1alpha1.SmbShareScalingSpec{
+		AvailbilityMode: "clustered",
+		MinClusterSize:  2,
+	}
+	gconfig.ClusterSupport = "ctdb-is-experimental"
+	assert.Equal(t,
+		[]string{"--listen=:8080", "--clustered"},
+		planner.metricsExporterArgs())
+}
+
+func TestPlannerAudit(t *testing.T) {
+	share := &sambaoperatorv1alpha1.SmbShare{
+		Spec: sambaoperatorv1alpha1.SmbShareSpec{
+			ShareName:  "audited",
+			Browseable: true,
+		},
+	}
+	gconfig := &conf.OperatorConfig{SambaDebugLevel: "2"}
+	planner := newSharePlanner(
+		InstanceConfiguration{
+			GlobalConfig: gconfig,
+			SmbShare:     share,
+		},
+		smbcc.New())
+	_, err := planner.update()
+	assert.NoError(t, err)
+	opts := planner.ConfigState.Shares["audited"].Options
+	assert.NotContains(t, opts, "vfs objects")
+	assert.Equal(t, "2", planner.sambaDebugLevel(smbdComponent))
+
+	share.Spec.Audit = &sambaoperatorv1alpha1.SmbShareAuditSpec{
+		Operations: []sambaoperatorv1alpha1.SmbAuditOperation{
+			"openat", "unlinkat",
+		},
+		Success: true,
+	}
+	opts["vfs objects"] = "acl_xattr"
+	changed, err := planner.update()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "acl_xattr full_audit", opts["vfs objects"])
+	assert.Equal(t, "openat unlinkat", opts["full_audit:success"])
+	assert.Equal(t, "none", opts["full_audit:failure"])
+	assert.Equal(t, "yes", opts["full_audit:syslog"])
+	assert.Equal(t, "LOCAL5", opts["full_audit:facility"])
+	assert.Equal(t, "%u|%D|%I|%m|%S", opts["full_audit:prefix"])
+	assert.Equal(t, "2", planner.sambaDebugLevel(smbdComponent))
+
+	changed, err = planner.update()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	share.Spec.Audit = nil
+	changed, err = planner.update()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "acl_xattr", opts["vfs objects"])
+	assert.NotContains(t, opts, "full_audit:success")
+	assert.NotContains(t, opts, "full_audit:prefix")
+	assert.NotContains(t, opts, "full_audit:facility")
+}
+
+func TestPlannerAuditLog(t *testing.T) {
+	share := &sambaoperatorv1alpha1.SmbShare{
+		Spec: sambaoperatorv1alpha1.SmbShareSpec{
+			ShareName: "audited",
+			Storage: sambaoperatorv1alpha1.SmbShareStorageSpec{
+				Pvc: &sambaoperatorv1alpha1.SmbSharePvcSpec{Name: "data"},
+			},
+		},
+	}
+	gconfig := &conf.OperatorConfig{
+		SmbdContainerName:     "samba",
+		SmbdContainerImage:    "samba:1",
+		MetricsContainerImage: "metrics:1",
+	}
+	planner := newSharePlanner(
+		InstanceConfiguration{
+			GlobalConfig: gconfig,
+			SmbShare:     share,
+		},
+		smbcc.New())
+	podSpec := buildPodSpec(planner, gconfig, "data")
+	tmpl := &corev1.PodTemplateSpec{Spec: *podSpec.DeepCopy()}
+	assert.False(t, syncAuditLog(planner, tmpl))
+
+	share.Spec.Audit = &sambaoperatorv1alpha1.SmbShareAuditSpec{}
+	audited := buildPodSpec(planner, gconfig, "data")
+	require.Len(t, audited.Containers, 2)
+	smbd, sidecar := audited.Containers[0], audited.Containers[1]
+	assert.Equal(t, []string{
+		"/bin/sh",
+		"-c",
+		`ln -sf '/run/samba-audit/log' /dev/log && exec samba-container "$@"`,
+		"samba-container",
+	}, smbd.Command)
+	assert.Equal(t, podSpec.Containers[0].Args, smbd.Args)
+	assert.Equal(t, "audit-log", sidecar.Name)
+	assert.Equal(t, "metrics:1", sidecar.Image)
+	assert.Equal(t, []string{"--socket=/run/samba-audit/log"}, sidecar.Args)
+	assert.True(t, *sidecar.SecurityContext.RunAsNonRoot)
+	assert.Equal(t, "/run/samba-audit", sidecar.VolumeMounts[0].MountPath)
+	assert.Contains(t, smbd.VolumeMounts, sidecar.VolumeMounts[0])
+
+	assert.True(t, syncAuditLog(planner, tmpl))
+	assert.Equal(t, audited, tmpl.Spec)
+	assert.False(t, syncAuditLog(planner, tmpl))
+
+	share.Spec.Audit = nil
+	assert.True(t, syncAuditLog(planner, tmpl))
+	assert.Equal(t, podSpec, tmpl.Spec)
+}
+
+func TestPlannerAccess(t *testing.T) {
+	share := &sambaoperatorv1alpha1.SmbShare{
+		Spec: sambaoperatorv1alpha1.SmbShareSpec{ShareName: "restricted"},
+	}
+	planner := newSharePlanner(
+		InstanceConfiguration{
+			GlobalConfig: &conf.OperatorConfig{},
+			SmbShare:     share,
+		},
+		smbcc.New())
+	_, err := planner.update()
+	assert.NoError(t, err)
+	opts := planner.ConfigState.Shares["restricted"].Options
+	assert.NotContains(t, opts, "valid users")
+
+	share.Spec.Access = &sambaoperatorv1alpha1.SmbShareAccessSpec{
+		Users:  []string{"alice"},
+		Groups: []string{"staff", "domain users"},
+	}
+	changed, err := planner.update()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, `alice @staff "@domain users"`, opts["valid users"])
+
+	changed, err = planner.update()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	share.Spec.Access = nil
+	changed, err = planner.update()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotContains(t, opts, "valid users")
+}
+
+func TestPlannerStatePVC(t *testing.T) {
+	gconfig := &conf.OperatorConfig{
+		StatePVCSize: resource.MustParse("2Gi"),
+	}
+	share := &sambaoperatorv1alpha1.SmbShare{
+		Spec: sambaoperatorv1alpha1.SmbShareSpec{
+			Scaling: &sambaoperatorv1alpha1.SmbShareScalingSpec{
+				AvailbilityMode: "clustered",
+			},
+		},
+	}
+	planner := newSharePlanner(
+		InstanceConfiguration{
+			GlobalConfig: gconfig,
+			SmbShare:     share,
+		},
+		&smbcc.SambaContainerConfig{})
+
+	spec, err := planner.statePVCSpec()
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+		spec.AccessModes)
+	assert.Nil(t, spec.StorageClassName)
+	assert.Nil(t, spec.VolumeMode)
+	size := spec.Resources.Requests[corev1.ResourceStorage]
+	assert.True(t, size.Equal(resource.MustParse("2Gi")))
+
+	// operator defaults
+	gconfig.StatePVCStorageClass = "cephfs"
+	gconfig.StatePVCAccessModes = []corev1.PersistentVolumeAccessMode{
+		corev1.ReadWriteMany, corev1.ReadOnlyMany,
+	}
+	gconfig.StatePVCVolumeMode = corev1.PersistentVolumeFilesystem
+	spec, err = planner.statePVCSpec()
+	assert.NoError(t, err)
+	if assert.NotNil(t, spec.StorageClassName) {
+		assert.Equal(t, "cephfs", *spec.StorageClassName)
+	}
+	assert.Len(t, spec.AccessModes, 2)
+	if assert.NotNil(t, spec.VolumeMode) {
+		assert.Equal(t, corev1.PersistentVolumeFilesystem, *spec.VolumeMode)
+	}
+
+	// the share's settings take precedence
+	share.Spec.Scaling.StatePVC = &sambaoperatorv1alpha1.SmbShareStatePVCSpec{
+		StorageClassName: "nfs",
+	}
+	spec, err = planner.statePVCSpec()
+	assert.NoError(t, err)
+	assert.Equal(t, "nfs", *spec.StorageClassName)
+	assert.Len(t, spec.AccessModes, 2)
+
+	// the state PVC is mounted as a filesystem
+	block := corev1.PersistentVolumeBlock
+	share.Spec.Scaling.StatePVC.VolumeMode = &block
+	_, err = planner.statePVCSpec()
+	assert.Error(t, err)
+	share.Spec.Scaling.StatePVC.VolumeMode = nil
+
+	// the state PVC must be ReadWriteMany
+	share.Spec.Scaling.StatePVC.AccessModes = []corev1.PersistentVolumeAccessMode{
+		corev1.ReadWriteOnce,
+	}
+	_, err = planner.statePVCSpec()
+	assert.Error(t, err)
+}
@@ -19,7 +19,7 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/api/equality"
 
 	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
 )
@@ -29,20 +29,104 @@ func buildPodSpec(
 	cfg *conf.OperatorConfig,
 	pvcName string) corev1.PodSpec {
 	// ---
+	var podSpec corev1.PodSpec
 	if planner.securityMode() == adMode {
-		return buildADPodSpec(planner, cfg, pvcName)
+		podSpec = buildADPodSpec(planner, cfg, pvcName)
+	} else {
+		podSpec = buildUserPodSpec(planner, cfg, pvcName)
 	}
-	return buildUserPodSpec(planner, cfg, pvcName)
+	applyPodScheduling(planner, &podSpec)
+	applyAuditLog(planner, &podSpec)
+	applyPodSecurity(planner, &podSpec)
+	podSpec.ImagePullSecrets = planner.imagePullSecrets()
+	applyPodExtras(planner, &podSpec)
+	gracePeriod := planner.terminationGracePeriod()
+	podSpec.TerminationGracePeriodSeconds = &gracePeriod
+	return podSpec
 }
 
 func buildClusteredPodSpec(
 	planner *sharePlanner,
 	dataPVCName, statePVCName string) corev1.PodSpec {
 	// ---
+	var podSpec corev1.PodSpec
 	if planner.securityMode() == adMode {
-		return buildClusteredADPodSpec(planner, dataPVCName, statePVCName)
+		podSpec = buildClusteredADPodSpec(planner, dataPVCName, statePVCName)
+	} else {
+		podSpec = buildClusteredUserPodSpec(planner, dataPVCName, statePVCName)
+	}
+	applyPodScheduling(planner, &podSpec)
+	applyAuditLog(planner, &podSpec)
+	applyPodSecurity(planner, &podSpec)
+	podSpec.ImagePullSecrets = planner.imagePullSecrets()
+	applyPodExtras(planner, &podSpec)
+	gracePeriod := planner.terminationGracePeriod()
+	podSpec.TerminationGracePeriodSeconds = &gracePeriod
+	return podSpec
+}
+
+// applyPodScheduling sets the scheduling related fields of the pod spec
+// based on the pod settings of the common config and share.
+func applyPodScheduling(planner *sharePlanner, podSpec *corev1.PodSpec) {
+	podSpec.NodeSelector = planner.nodeSelector()
+	podSpec.Tolerations = planner.tolerations()
+	podSpec.Affinity = planner.affinity()
+	podSpec.TopologySpreadConstraints = planner.topologySpreadConstraints()
+	podSpec.PriorityClassName = planner.priorityClassName()
+}
+
+// applyPodExtras adds the user defined containers, init containers and
+// volumes to the pod spec. Names are not checked here, see checkPodNames.
+func applyPodExtras(planner *sharePlanner, podSpec *corev1.PodSpec) {
+	podSpec.InitContainers = append(
+		podSpec.InitContainers, planner.extraInitContainers()...)
+	podSpec.Containers = append(
+		podSpec.Containers, planner.extraContainers()...)
+	podSpec.Volumes = append(podSpec.Volumes, planner.extraVolumes()...)
+}
+
+// checkPodExtras returns an error if the share defines containers, init
+// containers or volumes of its own but its pods are hosted in the working
+// namespace. Volumes and the environment of containers can refer to any
+// secret, config map or PVC of the pods' namespace, including those of the
+// other shares, so they are only allowed in the share's own namespace.
+func checkPodExtras(planner *sharePlanner) error {
+	if !planner.centralized() {
+		return nil
+	}
+	if len(planner.extraContainers()) > 0 ||
+		len(planner.extraInitContainers()) > 0 ||
+		len(planner.extraVolumes()) > 0 {
+		return fmt.Errorf(
+			"containers, init containers and volumes can not be added" +
+				" to pods in the working namespace")
+	}
+	return nil
+}
+
+// checkPodNames returns an error if the names of the containers or of the
+// volumes of the pod spec are not unique. As the names the operator uses
+// are unique this detects user defined containers and volumes that collide
+// with the operator's own or with each other.
+func checkPodNames(podSpec *corev1.PodSpec) error {
+	ctrNames := map[string]bool{}
+	ctrs := append(
+		append([]corev1.Container{}, podSpec.InitContainers...),
+		podSpec.Containers...)
+	for _, c := range ctrs {
+		if ctrNames[c.Name] {
+			return fmt.Errorf("container name %q is already in use", c.Name)
+		}
+		ctrNames[c.Name] = true
+	}
+	volNames := map[string]bool{}
+	for _, v := range podSpec.Volumes {
+		if volNames[v.Name] {
+			return fmt.Errorf("volume name %q is already in use", v.Name)
+		}
+		volNames[v.Name] = true
 	}
-	return buildClusteredUserPodSpec(planner, dataPVCName, statePVCName)
+	return nil
 }
 
 func buildADPodSpec(
@@ -92,6 +176,10 @@ func buildADPodSpec(
 		buildSmbdCtr(planner, podEnv, smbdVols),
 		buildWinbinddCtr(planner, podEnv, smbServerVols),
 	}
+	if planner.metricsEnabled() {
+		containers = append(
+			containers, buildSmbMetricsCtr(planner, podEnv, smbServerVols))
+	}
 
 	if planner.dnsRegister() != dnsRegisterNever {
 		watchVol := svcWatchVolumeAndMount(
@@ -141,12 +229,22 @@ func buildUserPodSpec(
 		v := userConfigVolumeAndMount(planner)
 		vols = append(vols, v)
 	}
+	if planner.metricsEnabled() {
+		// the exporter reads the state of smbd
+		stateVol := sambaStateVolumeAndMount(planner)
+		vols = append(vols, stateVol)
+	}
 	podEnv := defaultPodEnv(planner)
+	containers := []corev1.Container{
+		buildSmbdCtr(planner, podEnv, vols),
+	}
+	if planner.metricsEnabled() {
+		containers = append(
+			containers, buildSmbMetricsCtr(planner, podEnv, vols))
+	}
 	podSpec := corev1.PodSpec{
-		Volumes: getVolumes(vols),
-		Containers: []corev1.Container{
-			buildSmbdCtr(planner, podEnv, vols),
-		},
+		Volumes:    getVolumes(vols),
+		Containers: containers,
 	}
 	return podSpec
 }
@@ -252,6 +350,10 @@ func buildClusteredUserPodSpec(
 	containers = append(
 		containers,
 		buildSmbdCtr(planner, podEnv, volumes))
+	if planner.metricsEnabled() {
+		containers = append(
+			containers, buildSmbMetricsCtr(planner, podEnv, volumes))
+	}
 
 	shareProcessNamespace := true
 	podSpec := corev1.PodSpec{
@@ -393,6 +495,10 @@ func buildClusteredADPodSpec(
 	containers = append(
 		containers,
 		buildSmbdCtr(planner, podEnv, volumes))
+	if planner.metricsEnabled() {
+		containers = append(
+			containers, buildSmbMetricsCtr(planner, podEnv, volumes))
+	}
 
 	// dns-register containers
 	if planner.dnsRegister() != dnsRegisterNever {
@@ -426,29 +532,26 @@ func buildSmbdCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image: planner.GlobalConfig.SmbdContainerImage,
-		Name:  planner.GlobalConfig.SmbdContainerName,
-		Args:  planner.runDaemonArgs("smbd"),
-		Env:   env,
+		Image:           planner.containerImage(smbdComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            planner.GlobalConfig.SmbdContainerName,
+		Args:            planner.runDaemonArgs("smbd"),
+		Env: withDebugLevel(
+			env, planner.sambaDebugLevel(smbdComponent)),
 		Ports: []corev1.ContainerPort{{
-			ContainerPort: 445,
+			ContainerPort: smbPort,
 			Name:          "smb",
 		}},
-		VolumeMounts: getMounts(vols),
-		ReadinessProbe: &corev1.Probe{
-			Handler: corev1.Handler{
-				TCPSocket: &corev1.TCPSocketAction{
-					Port: intstr.FromInt(445),
-				},
-			},
-		},
-		LivenessProbe: &corev1.Probe{
-			Handler: corev1.Handler{
-				TCPSocket: &corev1.TCPSocketAction{
-					Port: intstr.FromInt(445),
-				},
-			},
-		},
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(smbdComponent),
+		SecurityContext: containerSecurityContext(false, smbdCapabilities),
+		Lifecycle:       preStopLifecycle(planner.smbdPreStopCmd()),
+		ReadinessProbe: buildExecProbe(
+			planner, readinessProbe, planner.smbdCheckCmd()),
+		LivenessProbe: buildExecProbe(
+			planner, livenessProbe, planner.smbdCheckCmd()),
+		StartupProbe: buildExecProbe(
+			planner, startupProbe, planner.smbdCheckCmd()),
 	}
 }
 
@@ -458,22 +561,22 @@ func buildWinbinddCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image:        planner.GlobalConfig.SmbdContainerImage,
-		Name:         planner.GlobalConfig.WinbindContainerName,
-		Args:         planner.runDaemonArgs("winbindd"),
-		Env:          env,
-		VolumeMounts: getMounts(vols),
-		LivenessProbe: &corev1.Probe{
-			Handler: corev1.Handler{
-				Exec: &corev1.ExecAction{
-					Command: []string{
-						"samba-container",
-						"check",
-						"winbind",
-					},
-				},
-			},
-		},
+		Image:           planner.containerImage(winbindComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            planner.GlobalConfig.WinbindContainerName,
+		Args:            planner.runDaemonArgs("winbindd"),
+		Env: withDebugLevel(
+			env, planner.sambaDebugLevel(winbindComponent)),
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(winbindComponent),
+		SecurityContext: containerSecurityContext(false, winbindCapabilities),
+		Lifecycle:       preStopLifecycle(planner.waitForSmbdCmd()),
+		ReadinessProbe: buildExecProbe(
+			planner, readinessProbe, planner.winbindCheckCmd()),
+		LivenessProbe: buildExecProbe(
+			planner, livenessProbe, planner.winbindCheckCmd()),
+		StartupProbe: buildExecProbe(
+			planner, startupProbe, planner.winbindCheckCmd()),
 	}
 }
 
@@ -483,11 +586,22 @@ func buildCTDBDaemonCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image:        planner.GlobalConfig.SmbdContainerImage,
-		Name:         "ctdb",
-		Args:         planner.ctdbDaemonArgs(),
-		Env:          env,
-		VolumeMounts: getMounts(vols),
+		Image:           planner.containerImage(ctdbComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            "ctdb",
+		Args:            planner.ctdbDaemonArgs(),
+		Env: withDebugLevel(
+			env, planner.sambaDebugLevel(ctdbComponent)),
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(ctdbComponent),
+		SecurityContext: containerSecurityContext(false, ctdbCapabilities),
+		Lifecycle:       preStopLifecycle(planner.waitForSmbdCmd()),
+		// ctdb has no liveness probe. restarting ctdbd because the node is
+		// unhealthy would only disrupt recovery of the cluster.
+		ReadinessProbe: buildExecProbe(
+			planner, readinessProbe, planner.ctdbCheckCmd()),
+		StartupProbe: buildExecProbe(
+			planner, startupProbe, planner.ctdbStartedCmd()),
 	}
 }
 
@@ -497,11 +611,14 @@ func buildCTDBManageNodesCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image:        planner.GlobalConfig.SmbdContainerImage,
-		Name:         "ctdb-manage-nodes",
-		Args:         planner.ctdbManageNodesArgs(),
-		Env:          env,
-		VolumeMounts: getMounts(vols),
+		Image:           planner.containerImage(ctdbComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            "ctdb-manage-nodes",
+		Args:            planner.ctdbManageNodesArgs(),
+		Env:             env,
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(ctdbComponent),
+		SecurityContext: containerSecurityContext(false, noCapabilities),
 	}
 }
 
@@ -511,11 +628,14 @@ func buildDNSRegCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image:        planner.GlobalConfig.SmbdContainerImage,
-		Name:         "dns-register",
-		Args:         planner.dnsRegisterArgs(),
-		Env:          env,
-		VolumeMounts: getMounts(vols),
+		Image:           planner.containerImage(dnsRegisterComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            "dns-register",
+		Args:            planner.dnsRegisterArgs(),
+		Env:             env,
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(dnsRegisterComponent),
+		SecurityContext: containerSecurityContext(false, noCapabilities),
 	}
 }
 
@@ -525,10 +645,50 @@ func buildSvcWatchCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image:        planner.GlobalConfig.SvcWatchContainerImage,
-		Name:         "svc-watch",
-		Env:          env,
-		VolumeMounts: getMounts(vols),
+		Image:           planner.containerImage(svcWatchComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            "svc-watch",
+		Env:             env,
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(svcWatchComponent),
+		SecurityContext: restrictedSecurityContext(),
+	}
+}
+
+func buildSmbMetricsCtr(
+	planner *sharePlanner,
+	env []corev1.EnvVar,
+	vols []volMount) corev1.Container {
+	// ---
+	return corev1.Container{
+		Image:           planner.containerImage(metricsComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            smbMetricsContainerName,
+		Args:            planner.metricsExporterArgs(),
+		Env:             env,
+		Ports: []corev1.ContainerPort{{
+			ContainerPort: metricsPort,
+			Name:          "metrics",
+		}},
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(metricsComponent),
+		SecurityContext: containerSecurityContext(true, noCapabilities),
+	}
+}
+
+func buildAuditLogCtr(
+	planner *sharePlanner,
+	vols []volMount) corev1.Container {
+	// ---
+	return corev1.Container{
+		Image:           planner.containerImage(auditLogComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            auditLogContainerName,
+		Command:         []string{"/usr/local/bin/smbaudit"},
+		Args:            []string{"--socket=" + auditSocketPath()},
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(auditLogComponent),
+		SecurityContext: restrictedSecurityContext(),
 	}
 }
 
@@ -538,11 +698,14 @@ func buildInitCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image:        planner.GlobalConfig.SmbdContainerImage,
-		Name:         "init",
-		Args:         planner.initializerArgs("init"),
-		Env:          env,
-		VolumeMounts: getMounts(vols),
+		Image:           planner.containerImage(initComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            "init",
+		Args:            planner.initializerArgs("init"),
+		Env:             env,
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(initComponent),
+		SecurityContext: containerSecurityContext(false, initCapabilities),
 	}
 }
 
@@ -552,11 +715,14 @@ func buildMustJoinCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image:        planner.GlobalConfig.SmbdContainerImage,
-		Name:         "must-join",
-		Args:         planner.initializerArgs("must-join"),
-		Env:          env,
-		VolumeMounts: getMounts(vols),
+		Image:           planner.containerImage(initComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            "must-join",
+		Args:            planner.initializerArgs("must-join"),
+		Env:             env,
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(initComponent),
+		SecurityContext: containerSecurityContext(false, initCapabilities),
 	}
 }
 
@@ -566,11 +732,14 @@ func buildCTDBMigrateCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image:        planner.GlobalConfig.SmbdContainerImage,
-		Name:         "ctdb-migrate",
-		Args:         planner.ctdbMigrateArgs(),
-		Env:          env,
-		VolumeMounts: getMounts(vols),
+		Image:           planner.containerImage(initComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            "ctdb-migrate",
+		Args:            planner.ctdbMigrateArgs(),
+		Env:             env,
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(initComponent),
+		SecurityContext: containerSecurityContext(false, initCapabilities),
 	}
 }
 
@@ -580,11 +749,14 @@ func buildCTDBSetNodeCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image:        planner.GlobalConfig.SmbdContainerImage,
-		Name:         "ctdb-set-node",
-		Args:         planner.ctdbSetNodeArgs(),
-		Env:          env,
-		VolumeMounts: getMounts(vols),
+		Image:           planner.containerImage(initComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            "ctdb-set-node",
+		Args:            planner.ctdbSetNodeArgs(),
+		Env:             env,
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(initComponent),
+		SecurityContext: containerSecurityContext(false, initCapabilities),
 	}
 }
 
@@ -594,11 +766,24 @@ func buildCTDBMustHaveNodeCtr(
 	vols []volMount) corev1.Container {
 	// ---
 	return corev1.Container{
-		Image:        planner.GlobalConfig.SmbdContainerImage,
-		Name:         "ctdb-must-have-node",
-		Args:         planner.ctdbMustHaveNodeArgs(),
-		Env:          env,
-		VolumeMounts: getMounts(vols),
+		Image:           planner.containerImage(initComponent),
+		ImagePullPolicy: planner.imagePullPolicy(),
+		Name:            "ctdb-must-have-node",
+		Args:            planner.ctdbMustHaveNodeArgs(),
+		Env:             env,
+		VolumeMounts:    getMounts(vols),
+		Resources:       planner.containerResources(initComponent),
+		SecurityContext: containerSecurityContext(false, initCapabilities),
+	}
+}
+
+func preStopLifecycle(cmd []string) *corev1.Lifecycle {
+	return &corev1.Lifecycle{
+		PreStop: &corev1.Handler{
+			Exec: &corev1.ExecAction{
+				Command: cmd,
+			},
+		},
 	}
 }
 
@@ -632,6 +817,13 @@ func svcWatchEnv(planner *sharePlanner) []corev1.EnvVar {
 	}
 }
 
+const sambaDebugLevelEnv = "SAMBA_DEBUG_LEVEL"
+
+const smbMetricsContainerName = "smbmetrics"
+
+// smbPort is the port smbd listens on.
+const smbPort = 445
+
 func defaultPodEnv(planner *sharePlanner) []corev1.EnvVar {
 	env := []corev1.EnvVar{
 		{
@@ -643,18 +835,153 @@ func defaultPodEnv(planner *sharePlanner) []corev1.EnvVar {
 			Value: planner.containerConfigPath(),
 		},
 	}
-	// In the future we may want per-container debug levels. The
-	// infrastructure could support that. For the moment we simply have one
-	// debug level for all samba containers in the pod.
+	// The daemon containers may override this level, see withDebugLevel.
 	if lvl := planner.sambaContainerDebugLevel(); lvl != "" {
 		env = append(env, corev1.EnvVar{
-			Name:  "SAMBA_DEBUG_LEVEL",
+			Name:  sambaDebugLevelEnv,
 			Value: lvl,
 		})
 	}
 	return env
 }
 
+// withDebugLevel returns a copy of env with the samba debug level set to
+// lvl. If lvl is empty the debug level variable is removed.
+func withDebugLevel(env []corev1.EnvVar, lvl string) []corev1.EnvVar {
+	out := make([]corev1.EnvVar, 0, len(env)+1)
+	for _, e := range env {
+		if e.Name != sambaDebugLevelEnv {
+			out = append(out, e)
+		}
+	}
+	if lvl != "" {
+		out = append(out, corev1.EnvVar{
+			Name:  sambaDebugLevelEnv,
+			Value: lvl,
+		})
+	}
+	return out
+}
+
+// syncDebugLevels updates the debug levels of the samba daemon containers
+// in the pod template to match the current configuration. It returns true
+// if the template was changed.
+func syncDebugLevels(
+	planner *sharePlanner,
+	tmpl *corev1.PodTemplateSpec) bool {
+	// ---
+	components := map[string]serverComponent{
+		planner.GlobalConfig.SmbdContainerName:    smbdComponent,
+		planner.GlobalConfig.WinbindContainerName: winbindComponent,
+		"ctdb": ctdbComponent,
+	}
+	changed := false
+	for i := range tmpl.Spec.Containers {
+		ctr := &tmpl.Spec.Containers[i]
+		c, found := components[ctr.Name]
+		if !found {
+			continue
+		}
+		lvl := planner.sambaDebugLevel(c)
+		current := ""
+		for _, e := range ctr.Env {
+			if e.Name == sambaDebugLevelEnv {
+				current = e.Value
+			}
+		}
+		if current == lvl {
+			continue
+		}
+		ctr.Env = withDebugLevel(ctr.Env, lvl)
+		changed = true
+	}
+	return changed
+}
+
+// containerComponents maps the names of the containers and init containers
+// built by the operator to their server components.
+func containerComponents(planner *sharePlanner) map[string]serverComponent {
+	return map[string]serverComponent{
+		planner.GlobalConfig.SmbdContainerName:    smbdComponent,
+		planner.GlobalConfig.WinbindContainerName: winbindComponent,
+		"ctdb":                  ctdbComponent,
+		"ctdb-manage-nodes":     ctdbComponent,
+		"dns-register":          dnsRegisterComponent,
+		"svc-watch":             svcWatchComponent,
+		smbMetricsContainerName: metricsComponent,
+		auditLogContainerName:   auditLogComponent,
+		"init":                  initComponent,
+		"must-join":             initComponent,
+		"ctdb-migrate":          initComponent,
+		"ctdb-set-node":         initComponent,
+		"ctdb-must-have-node":   initComponent,
+	}
+}
+
+// syncContainers updates the images, image pull policies and compute
+// resources of the containers built by the operator in the pod template to
+// match the current configuration, including the samba image annotation
+// and the pod settings of the share. Containers added through the pod
+// settings are left alone. It returns true if the template was changed.
+func syncContainers(
+	planner *sharePlanner,
+	tmpl *corev1.PodTemplateSpec) bool {
+	// ---
+	components := containerComponents(planner)
+	pullPolicy := planner.imagePullPolicy()
+	changed := false
+	for _, ctrs := range [][]corev1.Container{
+		tmpl.Spec.InitContainers, tmpl.Spec.Containers,
+	} {
+		for i := range ctrs {
+			c, found := components[ctrs[i].Name]
+			if !found {
+				continue
+			}
+			if img := planner.containerImage(c); ctrs[i].Image != img {
+				ctrs[i].Image = img
+				changed = true
+			}
+			// an unset pull policy is defaulted by the API server
+			if pullPolicy != "" && ctrs[i].ImagePullPolicy != pullPolicy {
+				ctrs[i].ImagePullPolicy = pullPolicy
+				changed = true
+			}
+			r := defaultedResources(planner.containerResources(c))
+			if !equality.Semantic.DeepEqual(
+				defaultedResources(ctrs[i].Resources), r) {
+				ctrs[i].Resources = r
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// defaultedResources returns the resource requirements as the API server
+// stores them: requests that are not given default to the limits.
+func defaultedResources(
+	r corev1.ResourceRequirements) corev1.ResourceRequirements {
+	// ---
+	r = *r.DeepCopy()
+	for name, q := range r.Limits {
+		if _, found := r.Requests[name]; found {
+			continue
+		}
+		if r.Requests == nil {
+			r.Requests = corev1.ResourceList{}
+		}
+		r.Requests[name] = q.DeepCopy()
+	}
+	if len(r.Requests) == 0 {
+		r.Requests = nil
+	}
+	if len(r.Limits) == 0 {
+		r.Limits = nil
+	}
+	return r
+}
+
 func ctdbHostnameEnv(_ *sharePlanner) []corev1.EnvVar {
 	return []corev1.EnvVar{
 		{
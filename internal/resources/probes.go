@@ -0,0 +1,111 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+)
+
+type probeKind string
+
+const (
+	readinessProbe = probeKind("readiness")
+	livenessProbe  = probeKind("liveness")
+	startupProbe   = probeKind("startup")
+)
+
+type probeTiming struct {
+	initialDelaySeconds int32
+	periodSeconds       int32
+	timeoutSeconds      int32
+	failureThreshold    int32
+}
+
+// defaultProbeTiming returns the default timing for the kind of probe.
+// The checks are commands that themselves talk to the samba daemons, so
+// the timeouts are more generous than kubernetes' defaults.
+func defaultProbeTiming(kind probeKind) probeTiming {
+	t := probeTiming{
+		periodSeconds:    10,
+		timeoutSeconds:   5,
+		failureThreshold: 3,
+	}
+	if kind == startupProbe {
+		// allow up to five minutes for a container to start. joining a
+		// domain and starting winbind can be slow.
+		t.failureThreshold = 30
+	}
+	return t
+}
+
+// buildExecProbe returns a probe of the given kind that runs cmd in the
+// container.
+func buildExecProbe(
+	planner *sharePlanner, kind probeKind, cmd []string) *corev1.Probe {
+	// ---
+	t := planner.probeTiming(kind)
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			Exec: &corev1.ExecAction{
+				Command: cmd,
+			},
+		},
+		InitialDelaySeconds: t.initialDelaySeconds,
+		PeriodSeconds:       t.periodSeconds,
+		TimeoutSeconds:      t.timeoutSeconds,
+		FailureThreshold:    t.failureThreshold,
+	}
+}
+
+// probeTiming returns the timing for the kind of probe. Values from the
+// share take precedence over the common config, which take precedence over
+// the defaults.
+func (sp *sharePlanner) probeTiming(kind probeKind) probeTiming {
+	t := defaultProbeTiming(kind)
+	common, share := sp.podSettings()
+	for _, ps := range []*sambaoperatorv1alpha1.SmbPodSettingsSpec{common, share} {
+		if ps == nil || ps.Probes == nil {
+			continue
+		}
+		var pt *sambaoperatorv1alpha1.SmbProbeTimingSpec
+		switch kind {
+		case readinessProbe:
+			pt = ps.Probes.Readiness
+		case livenessProbe:
+			pt = ps.Probes.Liveness
+		case startupProbe:
+			pt = ps.Probes.Startup
+		}
+		if pt == nil {
+			continue
+		}
+		if pt.InitialDelaySeconds != nil {
+			t.initialDelaySeconds = *pt.InitialDelaySeconds
+		}
+		if pt.PeriodSeconds != nil {
+			t.periodSeconds = *pt.PeriodSeconds
+		}
+		if pt.TimeoutSeconds != nil {
+			t.timeoutSeconds = *pt.TimeoutSeconds
+		}
+		if pt.FailureThreshold != nil {
+			t.failureThreshold = *pt.FailureThreshold
+		}
+	}
+	return t
+}
@@ -0,0 +1,263 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+// RenderInput holds the resources a share and the configuration it
+// references are read from.
+type RenderInput struct {
+	Shares          []*sambaoperatorv1alpha1.SmbShare
+	SecurityConfigs []*sambaoperatorv1alpha1.SmbSecurityConfig
+	CommonConfigs   []*sambaoperatorv1alpha1.SmbCommonConfig
+}
+
+// RenderedShare is the configuration and the resources the operator would
+// create for a share.
+type RenderedShare struct {
+	Share           *sambaoperatorv1alpha1.SmbShare
+	ContainerConfig *smbcc.SambaContainerConfig
+	// SmbConf is the smb.conf text equivalent to ContainerConfig.
+	SmbConf string
+	// Objects are the resources hosting the share, in the order the
+	// operator creates them.
+	Objects []rtclient.Object
+}
+
+// Render computes the configuration and the resources the operator would
+// create for each share of the input, without accessing a cluster. Each
+// share is rendered as the only share of a new server group. Owner
+// references and secrets derived from other secrets are not rendered.
+// Resources read from files should have their defaults applied first, see
+// ApplyDefaults.
+func Render(
+	cfg *conf.OperatorConfig, in RenderInput) ([]RenderedShare, error) {
+	// ---
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := sambaoperatorv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	m := &SmbShareManager{scheme: scheme, cfg: cfg}
+	var result []RenderedShare
+	for _, s := range in.Shares {
+		r, err := m.render(s.DeepCopy(), in)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to render SmbShare %s/%s: %w", s.Namespace, s.Name, err)
+		}
+		result = append(result, *r)
+	}
+	return result, nil
+}
+
+func (m *SmbShareManager) render(
+	s *sambaoperatorv1alpha1.SmbShare, in RenderInput) (*RenderedShare, error) {
+	// ---
+	if s.Status.ServerGroup == "" {
+		s.Status.ServerGroup = m.serverGroupName(s)
+	}
+	ic := InstanceConfiguration{SmbShare: s, GlobalConfig: m.cfg}
+	if s.Spec.SecurityConfig != "" {
+		for _, sc := range in.SecurityConfigs {
+			if sc.Namespace == s.Namespace && sc.Name == s.Spec.SecurityConfig {
+				ic.SecurityConfig = sc
+			}
+		}
+		if ic.SecurityConfig == nil {
+			return nil, fmt.Errorf(
+				"missing SmbSecurityConfig %q", s.Spec.SecurityConfig)
+		}
+	}
+	if s.Spec.CommonConfig != "" {
+		for _, cc := range in.CommonConfigs {
+			if cc.Namespace == s.Namespace && cc.Name == s.Spec.CommonConfig {
+				ic.CommonConfig = cc
+			}
+		}
+		if ic.CommonConfig == nil {
+			return nil, fmt.Errorf(
+				"missing SmbCommonConfig %q", s.Spec.CommonConfig)
+		}
+	}
+	planner := newSharePlanner(ic, smbcc.New())
+	if _, err := planner.update(); err != nil {
+		return nil, err
+	}
+	smbConf, err := planner.ConfigState.SmbConf(planner.instanceID())
+	if err != nil {
+		return nil, err
+	}
+
+	ns := m.serverNamespace(s)
+	cm, err := newDefaultConfigMap(planner.instanceName(), ns)
+	if err != nil {
+		return nil, err
+	}
+	if err := setContainerConfig(cm, planner.ConfigState); err != nil {
+		return nil, err
+	}
+	objects := []rtclient.Object{cm}
+	claimName := ""
+	if s.Spec.Storage.Pvc != nil {
+		claimName = pvcName(planner)
+		if shareNeedsPvc(s) {
+			objects = append(objects,
+				newPVC(claimName, ns, s.Spec.Storage.Pvc.Spec))
+		}
+	}
+	if planner.isClustered() {
+		spec, err := planner.statePVCSpec()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects,
+			newPVC(sharedStatePVCName(planner), ns, spec))
+	}
+	if err := checkPodExtras(planner); err != nil {
+		return nil, err
+	}
+	for _, o := range plannedObjects(m.cfg, planner, claimName, ns) {
+		var err error
+		switch obj := o.desired.(type) {
+		case *appsv1.Deployment:
+			err = checkPodNames(&obj.Spec.Template.Spec)
+		case *appsv1.StatefulSet:
+			err = checkPodNames(&obj.Spec.Template.Spec)
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, o.desired)
+	}
+	for _, o := range objects {
+		gvk, err := apiutil.GVKForObject(o, m.scheme)
+		if err != nil {
+			return nil, err
+		}
+		o.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	return &RenderedShare{
+		Share:           s,
+		ContainerConfig: planner.ConfigState,
+		SmbConf:         smbConf,
+		Objects:         objects,
+	}, nil
+}
+
+// crdDefault is a default value declared by a CRD of the operator. The
+// path names the field, with a "[]" suffix selecting every item of a list.
+type crdDefault struct {
+	path  string
+	value interface{}
+}
+
+// crdDefaults lists the default values declared by the CRDs of the
+// operator, by kind. TestCRDDefaults compares the table with the CRDs in
+// config/crd/bases; update both when a default marker changes.
+var crdDefaults = map[string][]crdDefault{
+	"SmbShare": {
+		{"spec.readOnly", false},
+		{"spec.browseable", true},
+		{"spec.audit.success", true},
+		{"spec.audit.failure", true},
+		{"spec.scaling.nodeSpread.topologyKey", "kubernetes.io/hostname"},
+		{"spec.podSettings.containers[].ports[].protocol", "TCP"},
+		{"spec.podSettings.initContainers[].ports[].protocol", "TCP"},
+	},
+	"SmbSecurityConfig": {
+		{"spec.joinSources[].userJoin.key", "join.json"},
+	},
+	"SmbCommonConfig": {
+		{"spec.podSettings.containers[].ports[].protocol", "TCP"},
+		{"spec.podSettings.initContainers[].ports[].protocol", "TCP"},
+	},
+	"SmbUser": {
+		{"spec.password.key", "password"},
+	},
+}
+
+// ApplyDefaults sets the default values declared by the CRD of a resource
+// of the operator on the fields the resource omits, as the API server does
+// when the resource is stored. obj is the resource decoded from JSON or
+// YAML. Resources of other kinds are left alone.
+func ApplyDefaults(obj map[string]interface{}) {
+	apiVersion, _ := obj["apiVersion"].(string)
+	group := sambaoperatorv1alpha1.GroupVersion.Group
+	if !strings.HasPrefix(apiVersion, group+"/") {
+		return
+	}
+	kind, _ := obj["kind"].(string)
+	for _, d := range crdDefaults[kind] {
+		setDefault(obj, strings.Split(d.path, "."), d.value)
+	}
+}
+
+// setDefault sets the field at path to value if the field is missing but
+// its parent exists.
+func setDefault(
+	obj map[string]interface{}, path []string, value interface{}) {
+	// ---
+	name := path[0]
+	if len(path) == 1 {
+		if _, found := obj[name]; !found {
+			obj[name] = value
+		}
+		return
+	}
+	if strings.HasSuffix(name, "[]") {
+		items, _ := obj[strings.TrimSuffix(name, "[]")].([]interface{})
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				setDefault(m, path[1:], value)
+			}
+		}
+		return
+	}
+	if m, ok := obj[name].(map[string]interface{}); ok {
+		setDefault(m, path[1:], value)
+	}
+}
+
+func newPVC(
+	name, ns string,
+	spec *corev1.PersistentVolumeClaimSpec) *corev1.PersistentVolumeClaim {
+	// ---
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: *spec,
+	}
+}
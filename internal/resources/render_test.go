@@ -0,0 +1,293 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+func TestRender(t *testing.T) {
+	cfg := &conf.OperatorConfig{
+		WorkingNamespace:   "samba-operator-system",
+		SmbdContainerImage: "quay.io/samba.org/samba-server:latest",
+		SmbdContainerName:  "samba",
+		StatePVCSize:       resource.MustParse("1Gi"),
+		ClusterSupport:     conf.ClusterSupportCTDBExperimental,
+	}
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "rtest", Name: "s1"},
+		Spec: sambaoperatorv1alpha1.SmbShareSpec{
+			ShareName:      "Stuff",
+			SecurityConfig: "users",
+			Storage: sambaoperatorv1alpha1.SmbShareStorageSpec{
+				Pvc: &sambaoperatorv1alpha1.SmbSharePvcSpec{
+					Spec: &corev1.PersistentVolumeClaimSpec{},
+				},
+			},
+		},
+	}
+	security := &sambaoperatorv1alpha1.SmbSecurityConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "rtest", Name: "users"},
+		Spec: sambaoperatorv1alpha1.SmbSecurityConfigSpec{
+			Mode: "user",
+			Users: &sambaoperatorv1alpha1.SmbSecurityUsersSpec{
+				Secret: "users",
+				Key:    "demousers",
+			},
+		},
+	}
+
+	_, err := Render(cfg, RenderInput{
+		Shares: []*sambaoperatorv1alpha1.SmbShare{share},
+	})
+	assert.Error(t, err)
+
+	rendered, err := Render(cfg, RenderInput{
+		Shares:          []*sambaoperatorv1alpha1.SmbShare{share},
+		SecurityConfigs: []*sambaoperatorv1alpha1.SmbSecurityConfig{security},
+	})
+	require.NoError(t, err)
+	require.Len(t, rendered, 1)
+	r := rendered[0]
+	assert.Equal(t, "", share.Status.ServerGroup)
+	assert.Equal(t, "s1", r.Share.Status.ServerGroup)
+	assert.Contains(t, r.ContainerConfig.Shares, smbcc.Key("Stuff"))
+	assert.Contains(t, r.SmbConf, "[Stuff]")
+	kinds := []string{}
+	for _, o := range r.Objects {
+		kinds = append(kinds, o.GetObjectKind().GroupVersionKind().Kind)
+		assert.Equal(t, "rtest", o.GetNamespace())
+	}
+	assert.Equal(t, []string{
+		"ConfigMap",
+		"PersistentVolumeClaim",
+		"Deployment",
+		"PodDisruptionBudget",
+		"Service",
+	}, kinds)
+	assert.Equal(t, "s1-pvc", r.Objects[1].GetName())
+
+	share.Spec.Scaling = &sambaoperatorv1alpha1.SmbShareScalingSpec{
+		AvailbilityMode: "clustered",
+		MinClusterSize:  2,
+	}
+	rendered, err = Render(cfg, RenderInput{
+		Shares:          []*sambaoperatorv1alpha1.SmbShare{share},
+		SecurityConfigs: []*sambaoperatorv1alpha1.SmbSecurityConfig{security},
+	})
+	require.NoError(t, err)
+	names := []string{}
+	for _, o := range rendered[0].Objects {
+		names = append(names,
+			o.GetObjectKind().GroupVersionKind().Kind+"/"+o.GetName())
+	}
+	assert.Equal(t, []string{
+		"ConfigMap/s1",
+		"PersistentVolumeClaim/s1-pvc",
+		"PersistentVolumeClaim/s1-state",
+		"StatefulSet/s1",
+		"PodDisruptionBudget/s1",
+		"Service/s1",
+	}, names)
+}
+
+func TestRenderContainerConfigVersion(t *testing.T) {
+	cfg := &conf.OperatorConfig{
+		WorkingNamespace:   "samba-operator-system",
+		SmbdContainerImage: "quay.io/samba.org/samba-server:latest",
+		SmbdContainerName:  "samba",
+	}
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "rtest", Name: "s1"},
+		Spec: sambaoperatorv1alpha1.SmbShareSpec{
+			SecurityConfig: "users",
+			Storage: sambaoperatorv1alpha1.SmbShareStorageSpec{
+				Pvc: &sambaoperatorv1alpha1.SmbSharePvcSpec{Name: "data"},
+			},
+		},
+	}
+	security := &sambaoperatorv1alpha1.SmbSecurityConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "rtest", Name: "users"},
+		Spec: sambaoperatorv1alpha1.SmbSecurityConfigSpec{
+			Mode: "user",
+			Users: &sambaoperatorv1alpha1.SmbSecurityUsersSpec{
+				Secret: "users",
+				Key:    "demousers",
+			},
+		},
+	}
+	in := RenderInput{
+		Shares:          []*sambaoperatorv1alpha1.SmbShare{share},
+		SecurityConfigs: []*sambaoperatorv1alpha1.SmbSecurityConfig{security},
+	}
+	for _, version := range []string{"", smbcc.CurrentVersion} {
+		cfg.SambaContainerConfigVersion = version
+		rendered, err := Render(cfg, in)
+		require.NoError(t, err)
+		r := rendered[0]
+		cm := r.Objects[0].(*corev1.ConfigMap)
+		_, stored, err := smbcc.Parse([]byte(cm.Data[ConfigJSONKey]))
+		require.NoError(t, err)
+		if version == "" {
+			version = smbcc.OldestVersion
+		}
+		assert.Equal(t, version, stored)
+
+		// the pod reads the users config, holding any group memberships,
+		// along with the container config
+		dep := r.Objects[1].(*appsv1.Deployment)
+		pod := dep.Spec.Template.Spec
+		env := map[string]string{}
+		for _, e := range pod.Containers[0].Env {
+			env[e.Name] = e.Value
+		}
+		assert.Equal(t,
+			"/etc/container-config/config.json:/etc/container-users/users.json",
+			env["SAMBACC_CONFIG"])
+		found := false
+		for _, v := range pod.Volumes {
+			if v.Secret != nil && v.Secret.SecretName == "s1-smbusers" {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	share := map[string]interface{}{
+		"apiVersion": "samba-operator.samba.org/v1alpha1",
+		"kind":       "SmbShare",
+		"spec": map[string]interface{}{
+			"browseable": false,
+			"audit":      map[string]interface{}{"failure": false},
+		},
+	}
+	ApplyDefaults(share)
+	spec := share["spec"].(map[string]interface{})
+	assert.Equal(t, false, spec["readOnly"])
+	assert.Equal(t, false, spec["browseable"])
+	audit := spec["audit"].(map[string]interface{})
+	assert.Equal(t, true, audit["success"])
+	assert.Equal(t, false, audit["failure"])
+	assert.NotContains(t, spec, "scaling")
+
+	security := map[string]interface{}{
+		"apiVersion": "samba-operator.samba.org/v1alpha1",
+		"kind":       "SmbSecurityConfig",
+		"spec": map[string]interface{}{
+			"joinSources": []interface{}{
+				map[string]interface{}{
+					"userJoin": map[string]interface{}{"secret": "join1"},
+				},
+				map[string]interface{}{
+					"userJoin": map[string]interface{}{"key": "other.json"},
+				},
+			},
+		},
+	}
+	ApplyDefaults(security)
+	sources := security["spec"].(map[string]interface{})["joinSources"].([]interface{})
+	for i, key := range []string{"join.json", "other.json"} {
+		join := sources[i].(map[string]interface{})["userJoin"]
+		assert.Equal(t, key, join.(map[string]interface{})["key"])
+	}
+
+	cm := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "SmbShare",
+		"spec":       map[string]interface{}{},
+	}
+	ApplyDefaults(cm)
+	assert.Empty(t, cm["spec"])
+}
+
+// TestCRDDefaults checks that crdDefaults lists the defaults declared by
+// every CRD of the operator, so that the table follows the default markers
+// of the API types.
+func TestCRDDefaults(t *testing.T) {
+	files, err := filepath.Glob(
+		filepath.Join("..", "..", "config", "crd", "bases", "*.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	kinds := map[string]bool{}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		crd := struct {
+			Spec struct {
+				Names struct {
+					Kind string `json:"kind"`
+				} `json:"names"`
+				Versions []struct {
+					Schema struct {
+						OpenAPIV3Schema map[string]interface{} `json:"openAPIV3Schema"`
+					} `json:"schema"`
+				} `json:"versions"`
+			} `json:"spec"`
+		}{}
+		require.NoError(t, yaml.Unmarshal(b, &crd))
+		require.Len(t, crd.Spec.Versions, 1)
+		kind := crd.Spec.Names.Kind
+		kinds[kind] = true
+		declared := map[string]interface{}{}
+		collectDefaults(
+			crd.Spec.Versions[0].Schema.OpenAPIV3Schema, nil, declared)
+		listed := map[string]interface{}{}
+		for _, d := range crdDefaults[kind] {
+			listed[d.path] = d.value
+		}
+		assert.Equal(t, declared, listed, kind)
+	}
+	for kind := range crdDefaults {
+		assert.True(t, kinds[kind], "no CRD of kind %s", kind)
+	}
+}
+
+func collectDefaults(
+	schema map[string]interface{},
+	path []string,
+	defaults map[string]interface{}) {
+	// ---
+	if value, found := schema["default"]; found {
+		defaults[strings.Join(path, ".")] = value
+	}
+	props, _ := schema["properties"].(map[string]interface{})
+	for name, p := range props {
+		collectDefaults(p.(map[string]interface{}),
+			append(append([]string{}, path...), name), defaults)
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		last := len(path) - 1
+		itemPath := append(append([]string{}, path[:last]...), path[last]+"[]")
+		collectDefaults(items, itemPath, defaults)
+	}
+}
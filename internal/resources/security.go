@@ -0,0 +1,126 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The samba containers need to run as root, but every container drops all
+// capabilities and adds back only those it needs. All of the capabilities
+// used here are permitted by the "baseline" Pod Security Standard.
+var (
+	// smbd switches to the identity of the connected user (SETUID,
+	// SETGID), manages ownership and modes of files in the share on
+	// behalf of clients (CHOWN, DAC_OVERRIDE, FOWNER, FSETID), signals
+	// its child processes (KILL) and listens on port 445
+	// (NET_BIND_SERVICE).
+	smbdCapabilities = []corev1.Capability{
+		"CHOWN",
+		"DAC_OVERRIDE",
+		"FOWNER",
+		"FSETID",
+		"KILL",
+		"NET_BIND_SERVICE",
+		"SETGID",
+		"SETUID",
+	}
+
+	// winbindd switches identities (SETUID, SETGID), manages the state
+	// and socket directories it shares with smbd (CHOWN, DAC_OVERRIDE,
+	// FOWNER) and signals its child processes (KILL).
+	winbindCapabilities = []corev1.Capability{
+		"CHOWN",
+		"DAC_OVERRIDE",
+		"FOWNER",
+		"KILL",
+		"SETGID",
+		"SETUID",
+	}
+
+	// ctdbd runs event scripts as root that manage the shared state
+	// (CHOWN, DAC_OVERRIDE, FOWNER) and it monitors and signals the
+	// processes of the other containers in the pod (KILL).
+	ctdbCapabilities = []corev1.Capability{
+		"CHOWN",
+		"DAC_OVERRIDE",
+		"FOWNER",
+		"KILL",
+	}
+
+	// init containers create the users, groups and directories used by
+	// the samba daemons (CHOWN, DAC_OVERRIDE, FOWNER, SETGID, SETUID).
+	initCapabilities = []corev1.Capability{
+		"CHOWN",
+		"DAC_OVERRIDE",
+		"FOWNER",
+		"SETGID",
+		"SETUID",
+	}
+
+	// The ctdb-manage-nodes, dns-register, svc-watch and metrics
+	// exporter containers only talk to other processes and the api
+	// server. They need no capabilities. Apart from svc-watch, they still
+	// run as root to use the root owned sockets and databases of samba
+	// and ctdb.
+	noCapabilities = []corev1.Capability{}
+)
+
+// containerSecurityContext returns a security context that drops all
+// capabilities but those given. If readOnlyRoot is true the container's root
+// filesystem is mounted read-only. Most samba containers can not use a
+// read-only root filesystem because sambacc writes configuration files
+// (such as /etc/passwd and /etc/nsswitch.conf) at startup.
+func containerSecurityContext(
+	readOnlyRoot bool, caps []corev1.Capability) *corev1.SecurityContext {
+	// ---
+	allowPrivilegeEscalation := false
+	add := make([]corev1.Capability, len(caps))
+	copy(add, caps)
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		ReadOnlyRootFilesystem:   &readOnlyRoot,
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+			Add:  add,
+		},
+	}
+}
+
+// unprivilegedUser is the user and group id of containers that do not need
+// to run as root: the conventional id of the "nobody" user.
+const unprivilegedUser = int64(65534)
+
+// restrictedSecurityContext returns a security context that meets the
+// "restricted" Pod Security Standard: the container runs as a non-root
+// user, with no capabilities and a read-only root filesystem. Together with
+// the runtime default seccomp profile set on the pod, it is used for the
+// containers that need no access to the root owned files of samba.
+func restrictedSecurityContext() *corev1.SecurityContext {
+	sc := containerSecurityContext(true, noCapabilities)
+	runAsNonRoot := true
+	uid := unprivilegedUser
+	gid := unprivilegedUser
+	sc.RunAsNonRoot = &runAsNonRoot
+	sc.RunAsUser = &uid
+	sc.RunAsGroup = &gid
+	return sc
+}
+
+// applyPodSecurity sets the pod level security context of the pod spec.
+func applyPodSecurity(planner *sharePlanner, podSpec *corev1.PodSpec) {
+	podSpec.SecurityContext = planner.podSecurityContext()
+}
@@ -0,0 +1,60 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+func TestContainerSecurityContext(t *testing.T) {
+	sc := containerSecurityContext(true, smbdCapabilities)
+	assert.False(t, *sc.AllowPrivilegeEscalation)
+	assert.True(t, *sc.ReadOnlyRootFilesystem)
+	assert.Equal(t, []corev1.Capability{"ALL"}, sc.Capabilities.Drop)
+	assert.Contains(t, sc.Capabilities.Add, corev1.Capability("NET_BIND_SERVICE"))
+
+	sc = containerSecurityContext(false, noCapabilities)
+	assert.False(t, *sc.ReadOnlyRootFilesystem)
+	assert.Len(t, sc.Capabilities.Add, 0)
+	assert.Nil(t, sc.RunAsNonRoot)
+}
+
+func TestRestrictedSecurityContext(t *testing.T) {
+	sc := restrictedSecurityContext()
+	assert.True(t, *sc.RunAsNonRoot)
+	assert.Equal(t, int64(65534), *sc.RunAsUser)
+	assert.Equal(t, int64(65534), *sc.RunAsGroup)
+	assert.False(t, *sc.AllowPrivilegeEscalation)
+	assert.True(t, *sc.ReadOnlyRootFilesystem)
+	assert.Equal(t, []corev1.Capability{"ALL"}, sc.Capabilities.Drop)
+	assert.Empty(t, sc.Capabilities.Add)
+
+	planner := newSharePlanner(InstanceConfiguration{
+		SmbShare:     &sambaoperatorv1alpha1.SmbShare{},
+		GlobalConfig: &conf.OperatorConfig{},
+	}, smbcc.New())
+	assert.Equal(t, sc, buildSvcWatchCtr(planner, nil, nil).SecurityContext)
+	metrics := buildSmbMetricsCtr(planner, nil, nil).SecurityContext
+	assert.True(t, *metrics.ReadOnlyRootFilesystem)
+	assert.Nil(t, metrics.RunAsNonRoot)
+}
@@ -18,10 +18,15 @@ package resources
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 var svcSelectorKey = "samba-operator.samba.org/service"
 
+// metricsLabel marks the services exposing the metrics exporters of the
+// pods hosting shares.
+const metricsLabel = "samba-operator.samba.org/metrics"
+
 func newServiceForSmb(planner *sharePlanner, ns string) *corev1.Service {
 	labels := labelsForSmbServer(planner.instanceName())
 	return &corev1.Service{
@@ -44,6 +49,36 @@ func newServiceForSmb(planner *sharePlanner, ns string) *corev1.Service {
 	}
 }
 
+// newMetricsServiceForSmb returns a service exposing the metrics exporters
+// of the server group. It is always internal to the cluster, even when the
+// shares are published externally.
+func newMetricsServiceForSmb(planner *sharePlanner, ns string) *corev1.Service {
+	labels := labelsForSmbServer(planner.instanceName())
+	svcLabels := map[string]string{metricsLabel: "true"}
+	for k, v := range labels {
+		svcLabels[k] = v
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      planner.instanceName() + "-metrics",
+			Namespace: ns,
+			Labels:    svcLabels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{{
+				Name:       "metrics",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       metricsPort,
+				TargetPort: intstr.FromString("metrics"),
+			}},
+			Selector: map[string]string{
+				svcSelectorKey: labels[svcSelectorKey],
+			},
+		},
+	}
+}
+
 func toServiceType(s string) corev1.ServiceType {
 	svcType := corev1.ServiceType(s)
 	switch svcType {
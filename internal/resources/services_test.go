@@ -0,0 +1,84 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+func TestDeleteMetricsService(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a", Name: "s1"},
+		Status:     sambaoperatorv1alpha1.SmbShareStatus{ServerGroup: "s1"},
+	}
+	cfg := &conf.OperatorConfig{}
+	planner := newSharePlanner(InstanceConfiguration{
+		SmbShare:     share,
+		GlobalConfig: cfg,
+	}, smbcc.New())
+	unlabeled := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "tenant-b",
+			Name:      "s1-metrics",
+		},
+	}
+	m := &SmbShareManager{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(
+				share,
+				newMetricsServiceForSmb(planner, "tenant-a"),
+				unlabeled).
+			Build(),
+		scheme: scheme,
+		logger: logr.Discard(),
+		cfg:    cfg,
+	}
+	ctx := context.TODO()
+	deleted, err := m.deleteMetricsService(ctx, planner, "tenant-a")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	key := types.NamespacedName{Namespace: "tenant-a", Name: "s1-metrics"}
+	err = m.client.Get(ctx, key, &corev1.Service{})
+	assert.True(t, errors.IsNotFound(err))
+	deleted, err = m.deleteMetricsService(ctx, planner, "tenant-a")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	// services not labeled as metrics services are left alone
+	deleted, err = m.deleteMetricsService(ctx, planner, "tenant-b")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
@@ -0,0 +1,438 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+// SmbSecurityConfigManager is used to manage SmbSecurityConfig resources.
+// It compiles the SmbUser and SmbGroup resources selected by a security
+// config into the users secret named by the config.
+type SmbSecurityConfigManager struct {
+	client   rtclient.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	logger   Logger
+}
+
+// NewSmbSecurityConfigManager creates a SmbSecurityConfigManager.
+func NewSmbSecurityConfigManager(
+	client rtclient.Client,
+	scheme *runtime.Scheme,
+	recorder record.EventRecorder,
+	logger Logger) *SmbSecurityConfigManager {
+	// ---
+	return &SmbSecurityConfigManager{
+		client:   client,
+		scheme:   scheme,
+		recorder: recorder,
+		logger:   logger,
+	}
+}
+
+// Process is called by the controller on any type of reconciliation.
+func (m *SmbSecurityConfigManager) Process(
+	ctx context.Context,
+	nsname types.NamespacedName) Result {
+	// ---
+	instance := &sambaoperatorv1alpha1.SmbSecurityConfig{}
+	err := m.client.Get(ctx, nsname, instance)
+	if errors.IsNotFound(err) {
+		// the compiled secret is owned by the config and garbage
+		// collected, only the statuses of the users are left to update
+		return Result{err: m.updateUserStatuses(ctx, nsname, nil)}
+	} else if err != nil {
+		m.logger.Error(
+			err,
+			"Failed to get SmbSecurityConfig",
+			"SmbSecurityConfig.Namespace", nsname.Namespace,
+			"SmbSecurityConfig.Name", nsname.Name)
+		return Result{err: err}
+	}
+	if instance.GetDeletionTimestamp() != nil || !compilesUsers(instance) {
+		return Result{err: m.updateUserStatuses(ctx, nsname, nil)}
+	}
+
+	included, err := m.updateCompiledUsers(ctx, instance)
+	if err != nil {
+		m.recorder.Eventf(instance,
+			EventWarning,
+			ReasonFailedCompileUsers,
+			"Failed to compile users: %s", err.Error())
+		return Result{err: err}
+	}
+	return Result{err: m.updateUserStatuses(ctx, nsname, included)}
+}
+
+// compilesUsers returns true if the users configuration of the security
+// config is compiled from SmbUser and SmbGroup resources.
+func compilesUsers(sc *sambaoperatorv1alpha1.SmbSecurityConfig) bool {
+	return sc.Spec.Mode == string(userMode) &&
+		sc.Spec.Users != nil &&
+		sc.Spec.Users.Selector != nil
+}
+
+// updateCompiledUsers compiles the selected users and groups into the
+// users secret of the security config. It returns the names of the
+// SmbUser resources included in the users configuration.
+func (m *SmbSecurityConfigManager) updateCompiledUsers(
+	ctx context.Context,
+	sc *sambaoperatorv1alpha1.SmbSecurityConfig) ([]string, error) {
+	// ---
+	selector, err := metav1.LabelSelectorAsSelector(sc.Spec.Users.Selector)
+	if err != nil {
+		return nil, err
+	}
+	opts := []rtclient.ListOption{
+		rtclient.InNamespace(sc.Namespace),
+		rtclient.MatchingLabelsSelector{Selector: selector},
+	}
+	users := &sambaoperatorv1alpha1.SmbUserList{}
+	if err := m.client.List(ctx, users, opts...); err != nil {
+		return nil, err
+	}
+	groups := &sambaoperatorv1alpha1.SmbGroupList{}
+	if err := m.client.List(ctx, groups, opts...); err != nil {
+		return nil, err
+	}
+	hashes, rotation, problems, err := m.userHashes(ctx, users.Items)
+	if err != nil {
+		return nil, err
+	}
+	cc, included, more := compileUsers(users.Items, groups.Items, hashes)
+	for _, p := range append(problems, more...) {
+		m.recorder.Event(p.obj, EventWarning, p.reason, p.message)
+	}
+	data, err := json.Marshal(cc)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := m.updateCompiledSecret(ctx, sc, data, rotation)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		m.recorder.Eventf(sc,
+			EventNormal,
+			ReasonCompiledUsers,
+			"Compiled %d users into Secret %s",
+			len(included), sc.Spec.Users.Secret)
+	}
+	return included, nil
+}
+
+// resourceProblem describes why a SmbUser or SmbGroup resource is not,
+// or only partly, included in a users configuration.
+type resourceProblem struct {
+	obj     rtclient.Object
+	reason  string
+	message string
+}
+
+// userHashes returns the NT hashes of the passwords of the users, by name
+// of the SmbUser resource, generating the passwords that are to be
+// generated. It also returns the digest of the rotations of the generated
+// passwords. Users whose password can not be read are left out and
+// reported as problems.
+func (m *SmbSecurityConfigManager) userHashes(
+	ctx context.Context,
+	users []sambaoperatorv1alpha1.SmbUser) (
+	map[string]string, string, []resourceProblem, error) {
+	// ---
+	var (
+		hashes    = map[string]string{}
+		rotations = map[string]string{}
+		problems  []resourceProblem
+		err       error
+	)
+	for i := range users {
+		u := &users[i]
+		invalid := func(format string, args ...interface{}) {
+			problems = append(problems, resourceProblem{
+				obj:     u,
+				reason:  ReasonInvalidSmbUser,
+				message: fmt.Sprintf(format, args...),
+			})
+		}
+		if u.Spec.Password == nil {
+			invalid("User has no password")
+			continue
+		}
+		secret := &corev1.Secret{}
+		if u.Spec.Password.Generate {
+			var problem string
+			secret, problem, err = m.updateGeneratedPassword(ctx, u)
+			if err != nil {
+				return nil, "", nil, err
+			} else if problem != "" {
+				invalid(problem)
+				continue
+			}
+			rotations[smbUserName(u)] =
+				secret.Annotations[passwordRotationAnnotation]
+		} else {
+			err = m.client.Get(ctx, types.NamespacedName{
+				Namespace: u.Namespace,
+				Name:      u.Spec.Password.Secret,
+			}, secret)
+			if errors.IsNotFound(err) {
+				invalid("Password secret %s not found", u.Spec.Password.Secret)
+				continue
+			} else if err != nil {
+				return nil, "", nil, err
+			}
+		}
+		key := passwordKey(u.Spec.Password)
+		password, found := secret.Data[key]
+		if !found {
+			invalid("Password secret %s has no key %s",
+				u.Spec.Password.Secret, key)
+			continue
+		}
+		hashes[u.Name] = smbcc.NTHash(string(password))
+	}
+	return hashes, rotationDigest(rotations), problems, nil
+}
+
+// compileUsers builds the users configuration of the given users and
+// groups. Only users with a NT hash are included. Users and groups whose
+// name is already taken are left out, as are memberships referencing
+// resources that are not included. The names of the included SmbUser
+// resources are returned along with the problems found.
+func compileUsers(
+	users []sambaoperatorv1alpha1.SmbUser,
+	groups []sambaoperatorv1alpha1.SmbGroup,
+	hashes map[string]string) (
+	*smbcc.SambaContainerConfig, []string, []resourceProblem) {
+	// ---
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name < users[j].Name
+	})
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	var problems []resourceProblem
+	problem := func(
+		obj rtclient.Object, reason, format string, args ...interface{}) {
+		// ---
+		problems = append(problems, resourceProblem{
+			obj:     obj,
+			reason:  reason,
+			message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	groupNames := map[string]string{}
+	taken := map[string]bool{}
+	groupEntries := smbcc.GroupEntries{}
+	var groupSources []*sambaoperatorv1alpha1.SmbGroup
+	for i := range groups {
+		g := &groups[i]
+		name := g.Spec.GroupName
+		if name == "" {
+			name = g.Name
+		}
+		if taken[name] {
+			problem(g, ReasonInvalidSmbGroup,
+				"Group name %s is used by another SmbGroup", name)
+			continue
+		}
+		taken[name] = true
+		groupNames[g.Name] = name
+		groupEntries = append(groupEntries, smbcc.GroupEntry{
+			Name: name,
+			Gid:  uint(g.Spec.Gid),
+		})
+		groupSources = append(groupSources, g)
+	}
+
+	userNames := map[string]string{}
+	taken = map[string]bool{}
+	userEntries := smbcc.UserEntries{}
+	var included []string
+	for i := range users {
+		u := &users[i]
+		hash, found := hashes[u.Name]
+		if !found {
+			continue
+		}
+		name := smbUserName(u)
+		if taken[name] {
+			problem(u, ReasonInvalidSmbUser,
+				"User name %s is used by another SmbUser", name)
+			continue
+		}
+		taken[name] = true
+		userNames[u.Name] = name
+		entry := smbcc.UserEntry{
+			Name:   name,
+			Uid:    uint(u.Spec.Uid),
+			Gid:    uint(u.Spec.Gid),
+			NTHash: hash,
+		}
+		for _, g := range u.Spec.Groups {
+			if gname, found := groupNames[g]; found {
+				entry.Groups = append(entry.Groups, gname)
+			} else {
+				problem(u, ReasonInvalidSmbUser,
+					"Group %s is not a selected SmbGroup", g)
+			}
+		}
+		userEntries = append(userEntries, entry)
+		included = append(included, u.Name)
+	}
+
+	for i, g := range groupSources {
+		for _, member := range g.Spec.Members {
+			if uname, found := userNames[member]; found {
+				groupEntries[i].Members = append(groupEntries[i].Members, uname)
+			} else {
+				problem(g, ReasonInvalidSmbGroup,
+					"Member %s is not an included SmbUser", member)
+			}
+		}
+	}
+
+	cc := &smbcc.SambaContainerConfig{
+		SCCVersion: smbcc.CurrentVersion,
+		Users: map[smbcc.Key]smbcc.UserEntries{
+			smbcc.AllEntriesKey: userEntries,
+		},
+	}
+	if len(groupEntries) > 0 {
+		cc.Groups = map[smbcc.Key]smbcc.GroupEntries{
+			smbcc.AllEntriesKey: groupEntries,
+		}
+	}
+	return cc, included, problems
+}
+
+// updateCompiledSecret creates or updates the users secret of the security
+// config with the compiled users configuration and the digest of the
+// password rotations. A secret that exists but is not owned by the
+// security config is left alone, to avoid replacing users managed by hand.
+// It returns true if the secret changed.
+func (m *SmbSecurityConfigManager) updateCompiledSecret(
+	ctx context.Context,
+	sc *sambaoperatorv1alpha1.SmbSecurityConfig,
+	data []byte,
+	rotation string) (bool, error) {
+	// ---
+	key := types.NamespacedName{
+		Namespace: sc.Namespace,
+		Name:      sc.Spec.Users.Secret,
+	}
+	secret := &corev1.Secret{}
+	err := m.client.Get(ctx, key, secret)
+	if errors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{sc.Spec.Users.Key: data},
+		}
+		setPasswordRotation(&secret.ObjectMeta, rotation)
+		err = controllerutil.SetControllerReference(sc, secret, m.scheme)
+		if err != nil {
+			return false, err
+		}
+		m.logger.Info("Creating compiled users Secret",
+			"Secret.Namespace", secret.Namespace,
+			"Secret.Name", secret.Name)
+		return true, m.client.Create(ctx, secret)
+	} else if err != nil {
+		return false, err
+	}
+	if !metav1.IsControlledBy(secret, sc) {
+		return false, fmt.Errorf(
+			"secret %s exists and is not owned by SmbSecurityConfig %s",
+			key.Name, sc.Name)
+	}
+	rotated := setPasswordRotation(&secret.ObjectMeta, rotation)
+	if bytes.Equal(secret.Data[sc.Spec.Users.Key], data) && !rotated {
+		return false, nil
+	}
+	secret.Data = map[string][]byte{sc.Spec.Users.Key: data}
+	m.logger.Info("Updating compiled users Secret",
+		"Secret.Namespace", secret.Namespace,
+		"Secret.Name", secret.Name)
+	return true, m.client.Update(ctx, secret)
+}
+
+// updateUserStatuses records in the status of the SmbUsers in the
+// namespace of the security config whether they are included in its
+// users configuration.
+func (m *SmbSecurityConfigManager) updateUserStatuses(
+	ctx context.Context,
+	nsname types.NamespacedName,
+	included []string) error {
+	// ---
+	isIncluded := map[string]bool{}
+	for _, name := range included {
+		isIncluded[name] = true
+	}
+	users := &sambaoperatorv1alpha1.SmbUserList{}
+	err := m.client.List(ctx, users, rtclient.InNamespace(nsname.Namespace))
+	if err != nil {
+		return err
+	}
+	for i := range users.Items {
+		u := &users.Items[i]
+		configs := make([]string, 0, len(u.Status.SecurityConfigs)+1)
+		listed := false
+		for _, name := range u.Status.SecurityConfigs {
+			if name == nsname.Name {
+				listed = true
+				continue
+			}
+			configs = append(configs, name)
+		}
+		if listed == isIncluded[u.Name] {
+			continue
+		}
+		if isIncluded[u.Name] {
+			configs = append(configs, nsname.Name)
+			sort.Strings(configs)
+		}
+		u.Status.SecurityConfigs = configs
+		if err := m.client.Status().Update(ctx, u); err != nil {
+			m.logger.Error(err, "Failed to update SmbUser status",
+				"SmbUser.Namespace", u.Namespace,
+				"SmbUser.Name", u.Name)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+func smbUser(
+	name string,
+	spec sambaoperatorv1alpha1.SmbUserSpec) sambaoperatorv1alpha1.SmbUser {
+	// ---
+	return sambaoperatorv1alpha1.SmbUser{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "utest",
+			Name:      name,
+			Labels:    map[string]string{"team": "a"},
+		},
+		Spec: spec,
+	}
+}
+
+func TestCompileUsers(t *testing.T) {
+	users := []sambaoperatorv1alpha1.SmbUser{
+		smbUser("carol", sambaoperatorv1alpha1.SmbUserSpec{
+			UserName: "alice",
+		}),
+		smbUser("alice", sambaoperatorv1alpha1.SmbUserSpec{
+			Uid:    1001,
+			Groups: []string{"staff", "ops"},
+		}),
+		smbUser("bob", sambaoperatorv1alpha1.SmbUserSpec{}),
+		smbUser("dave", sambaoperatorv1alpha1.SmbUserSpec{}),
+	}
+	groups := []sambaoperatorv1alpha1.SmbGroup{{
+		ObjectMeta: metav1.ObjectMeta{Name: "staff"},
+		Spec: sambaoperatorv1alpha1.SmbGroupSpec{
+			GroupName: "Staff",
+			Gid:       2000,
+			Members:   []string{"bob", "dave"},
+		},
+	}}
+	hashes := map[string]string{"alice": "A", "bob": "B", "carol": "C"}
+	cc, included, problems := compileUsers(users, groups, hashes)
+
+	assert.Equal(t, []string{"alice", "bob"}, included)
+	assert.Equal(t, smbcc.UserEntries{
+		{Name: "alice", Uid: 1001, NTHash: "A", Groups: []string{"Staff"}},
+		{Name: "bob", NTHash: "B"},
+	}, cc.Users[smbcc.AllEntriesKey])
+	assert.Equal(t, smbcc.GroupEntries{
+		{Name: "Staff", Gid: 2000, Members: []string{"bob"}},
+	}, cc.Groups[smbcc.AllEntriesKey])
+	assert.NoError(t, cc.Validate())
+
+	messages := []string{}
+	for _, p := range problems {
+		messages = append(messages, p.obj.GetName()+": "+p.message)
+	}
+	assert.Equal(t, []string{
+		"alice: Group ops is not a selected SmbGroup",
+		"carol: User name alice is used by another SmbUser",
+		"staff: Member dave is not an included SmbUser",
+	}, messages)
+}
+
+func TestSmbSecurityConfigManager(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	sc := &sambaoperatorv1alpha1.SmbSecurityConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "utest", Name: "sec"},
+		Spec: sambaoperatorv1alpha1.SmbSecurityConfigSpec{
+			Mode: "user",
+			Users: &sambaoperatorv1alpha1.SmbSecurityUsersSpec{
+				Secret: "compiled",
+				Key:    "users.json",
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"team": "a"},
+				},
+			},
+		},
+	}
+	password := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "utest", Name: "pw"},
+		Data:       map[string][]byte{"password": []byte("password")},
+	}
+	alice := smbUser("alice", sambaoperatorv1alpha1.SmbUserSpec{
+		Password: &sambaoperatorv1alpha1.SmbUserPasswordSpec{Secret: "pw"},
+	})
+	bob := smbUser("bob", sambaoperatorv1alpha1.SmbUserSpec{
+		Password: &sambaoperatorv1alpha1.SmbUserPasswordSpec{Secret: "nope"},
+	})
+	other := smbUser("other", sambaoperatorv1alpha1.SmbUserSpec{
+		Password: &sambaoperatorv1alpha1.SmbUserPasswordSpec{Secret: "pw"},
+	})
+	other.Labels = nil
+	recorder := record.NewFakeRecorder(10)
+	m := NewSmbSecurityConfigManager(
+		fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(sc, password, &alice, &bob, &other).
+			Build(),
+		scheme,
+		recorder,
+		logr.Discard())
+	ctx := context.TODO()
+	nsname := types.NamespacedName{Namespace: "utest", Name: "sec"}
+
+	res := m.Process(ctx, nsname)
+	require.NoError(t, res.Err())
+	compiled := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: "utest", Name: "compiled"}
+	require.NoError(t, m.client.Get(ctx, key, compiled))
+	assert.True(t, metav1.IsControlledBy(compiled, sc))
+	cc, _, err := smbcc.Parse(compiled.Data["users.json"])
+	require.NoError(t, err)
+	assert.Equal(t, smbcc.UserEntries{
+		{Name: "alice", NTHash: "8846F7EAEE8FB117AD06BDD830B7586C"},
+	}, cc.Users[smbcc.AllEntriesKey])
+	assert.Len(t, recorder.Events, 2)
+
+	status := func(name string) []string {
+		u := &sambaoperatorv1alpha1.SmbUser{}
+		require.NoError(t, m.client.Get(ctx,
+			types.NamespacedName{Namespace: "utest", Name: name}, u))
+		return u.Status.SecurityConfigs
+	}
+	assert.Equal(t, []string{"sec"}, status("alice"))
+	assert.Empty(t, status("bob"))
+	assert.Empty(t, status("other"))
+
+	// the users are no longer compiled once the config is deleted
+	require.NoError(t, m.client.Delete(ctx, sc))
+	require.NoError(t, m.Process(ctx, nsname).Err())
+	assert.Empty(t, status("alice"))
+
+	// secrets not owned by the security config are not replaced
+	sc.ResourceVersion = ""
+	sc.Spec.Users.Secret = "pw"
+	require.NoError(t, m.client.Create(ctx, sc))
+	assert.Error(t, m.Process(ctx, nsname).Err())
+}
@@ -21,9 +21,8 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	kresource "k8s.io/apimachinery/pkg/api/resource"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
@@ -32,6 +31,7 @@ import (
 
 	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
 	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
 )
 
 const shareFinalizer = "samba-operator.samba.org/shareFinalizer"
@@ -77,6 +77,7 @@ func (m *SmbShareManager) Process(
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found. Not a fatal error.
+			shareStates.forget(nsname)
 			return Done
 		}
 		m.logger.Error(
@@ -105,12 +106,28 @@ func (m *SmbShareManager) Update(
 	ctx context.Context,
 	instance *sambaoperatorv1alpha1.SmbShare) Result {
 	// ---
+	rm := newReconcileMetrics(instance)
+	result := m.update(ctx, instance, rm)
+	if err := result.Err(); err != nil {
+		m.recordFailure(instance, failureReason(rm.step, err), err)
+	}
+	rm.finish(result)
+	shareStates.set(instance, sharePhase(result), rm.securityMode)
+	return result
+}
+
+func (m *SmbShareManager) update(
+	ctx context.Context,
+	instance *sambaoperatorv1alpha1.SmbShare,
+	rm *reconcileMetrics) Result {
+	// ---
 	m.logger.Info(
 		"Updating state for SmbShare",
 		"SmbShare.Namespace", instance.Namespace,
 		"SmbShare.Name", instance.Name,
 		"SmbShare.UID", instance.UID)
 
+	rm.begin("finalizer", "SmbShare")
 	changed, err := m.addFinalizer(ctx, instance)
 	if err != nil {
 		return Result{err: err}
@@ -120,7 +137,9 @@ func (m *SmbShareManager) Update(
 	}
 
 	// assign the share to a Server Group. Currently we only support 1:1
-	// shares to servers & it simply reflects the name of the resource.
+	// shares to servers & it simply reflects the name of the resource, or
+	// its namespace and name if the servers are in the working namespace.
+	rm.begin("server-group", "SmbShare")
 	changed, err = m.setServerGroup(ctx, instance)
 	if err != nil {
 		return Result{err: err}
@@ -129,25 +148,39 @@ func (m *SmbShareManager) Update(
 		return Requeue
 	}
 
-	destNamespace := instance.Namespace
+	destNamespace := m.serverNamespace(instance)
+	rm.begin("configmap", "ConfigMap")
 	cm, created, err := m.getOrCreateConfigMap(ctx, instance, destNamespace)
 	if err != nil {
 		return Result{err: err}
 	} else if created {
 		m.logger.Info("Created config map")
+		m.recorder.Eventf(instance,
+			EventNormal,
+			ReasonCreatedConfigMap,
+			"Created config map %s for SmbShare", cm.Name)
 		return Requeue
 	}
-	planner, changed, err := m.updateConfiguration(ctx, cm, instance)
+	planner, changes, err := m.updateConfiguration(ctx, cm, instance)
 	if err != nil {
 		return Result{err: err}
-	} else if changed {
+	}
+	rm.securityMode = string(planner.securityMode())
+	rm.observeConfig(cm)
+	if len(changes) > 0 {
 		m.logger.Info("Updated config map")
+		m.recorder.Eventf(instance,
+			EventNormal,
+			ReasonUpdatedConfig,
+			"Updated samba configuration in config map %s: %s",
+			cm.Name, changes.Summary(maxEventChanges))
 		return Requeue
 	}
 
 	if shareNeedsPvc(instance) {
+		rm.begin("pvc", "PersistentVolumeClaim")
 		pvc, created, err := m.getOrCreatePvc(
-			ctx, instance, destNamespace)
+			ctx, planner, destNamespace)
 		if err != nil {
 			return Result{err: err}
 		} else if created {
@@ -160,8 +193,14 @@ func (m *SmbShareManager) Update(
 		}
 		// if name is unset in the YAML, set it here
 		instance.Spec.Storage.Pvc.Name = pvc.Name
+	} else if instance.Spec.Storage.Pvc != nil {
+		rm.begin("pvc", "PersistentVolumeClaim")
+		if err := m.checkNamedPvc(ctx, planner, destNamespace); err != nil {
+			return Result{err: err}
+		}
 	}
 
+	rm.begin("backend", "SmbShare")
 	hasBackend := instance.Annotations[serverBackend] != ""
 	if !hasBackend {
 		if instance.Annotations == nil {
@@ -198,10 +237,10 @@ func (m *SmbShareManager) Update(
 		// intelligent methods to handle changes like this.
 		b := instance.Annotations[serverBackend]
 		if planner.isClustered() && b != clusteredBackend {
-			err = fmt.Errorf(
+			err = withReason(ReasonBackendChangeRefused, fmt.Errorf(
 				"Can not convert SmbShare to clustered instance."+
 					" Current backend: %s",
-				b)
+				b))
 			m.logger.Error(
 				err,
 				"Backend inconsistency detected",
@@ -211,10 +250,10 @@ func (m *SmbShareManager) Update(
 			return Result{err: err}
 		}
 		if !planner.isClustered() && b != standardBackend {
-			err = fmt.Errorf(
+			err = withReason(ReasonBackendChangeRefused, fmt.Errorf(
 				"Can not convert SmbShare to non-clustered instance."+
 					" Current backend: %s",
-				b)
+				b))
 			m.logger.Error(
 				err,
 				"Backend inconsistency detected",
@@ -225,23 +264,55 @@ func (m *SmbShareManager) Update(
 		}
 	}
 
+	rm.begin("secrets", "Secret")
+	changed, err = m.syncSecrets(ctx, planner, destNamespace)
+	if err != nil {
+		return Result{err: err}
+	} else if changed {
+		m.logger.Info("Copied secrets")
+		m.recorder.Eventf(instance,
+			EventNormal,
+			ReasonCopiedSecrets,
+			"Copied secrets of SmbShare to namespace %s", destNamespace)
+		return Requeue
+	}
+
+	rm.begin("users-secret", "Secret")
+	changed, err = m.updateUsersSecret(ctx, planner, destNamespace)
+	if err != nil {
+		return Result{err: err}
+	} else if changed {
+		m.logger.Info("Updated users secret")
+		m.recorder.Eventf(instance,
+			EventNormal,
+			ReasonUpdatedUsersSecret,
+			"Updated users secret %s", planner.usersSecretName())
+		return Requeue
+	}
+
 	if planner.isClustered() {
 		if !planner.mayCluster() {
-			err = fmt.Errorf(
+			err = withReason(ReasonClusteringNotEnabled, fmt.Errorf(
 				"CTDB clustering not enabled in ClusterSupport: %v",
-				planner.GlobalConfig.ClusterSupport)
+				planner.GlobalConfig.ClusterSupport))
 			m.logger.Error(err, "Clustering support is not enabled")
 			return Result{err: err}
 		}
-		_, created, err := m.getOrCreateStatePVC(
+		rm.begin("state-pvc", "PersistentVolumeClaim")
+		statePVC, created, err := m.getOrCreateStatePVC(
 			ctx, planner, destNamespace)
 		if err != nil {
 			return Result{err: err}
 		} else if created {
 			m.logger.Info("Created shared state PVC")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonCreatedPersistentVolumeClaim,
+				"Created state PVC %s for SmbShare", statePVC.Name)
 			return Requeue
 		}
 
+		rm.begin("statefulset", "StatefulSet")
 		statefulSet, created, err := m.getOrCreateStatefulSet(
 			ctx, planner, destNamespace)
 		if err != nil {
@@ -255,7 +326,61 @@ func (m *SmbShareManager) Update(
 				"Created stateful set %s for SmbShare", statefulSet.Name)
 			return Requeue
 		}
+
+		changed, err := m.updateDebugLevels(
+			ctx, planner, statefulSet, &statefulSet.Spec.Template)
+		if err != nil {
+			return Result{err: err}
+		} else if changed {
+			m.logger.Info("Updated debug levels of StatefulSet")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonUpdatedDebugLevels,
+				"Updated debug levels of stateful set %s", statefulSet.Name)
+			return Requeue
+		}
+
+		changed, err = m.updateContainers(
+			ctx, planner, statefulSet, &statefulSet.Spec.Template)
+		if err != nil {
+			return Result{err: err}
+		} else if changed {
+			m.logger.Info("Updated containers of StatefulSet")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonUpdatedContainers,
+				"Updated containers of stateful set %s", statefulSet.Name)
+			return Requeue
+		}
+
+		changed, err = m.updateAuditLog(
+			ctx, planner, statefulSet, &statefulSet.Spec.Template)
+		if err != nil {
+			return Result{err: err}
+		} else if changed {
+			m.logger.Info("Updated audit log container of StatefulSet")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonUpdatedAuditLog,
+				"Updated audit log container of stateful set %s", statefulSet.Name)
+			return Requeue
+		}
+
+		changed, err = m.updatePasswordRotation(
+			ctx, planner, statefulSet, &statefulSet.Spec.Template)
+		if err != nil {
+			return Result{err: err}
+		} else if changed {
+			m.logger.Info("Rolled StatefulSet for rotated passwords")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonRotatedPasswords,
+				"Restarted pods of stateful set %s to apply rotated passwords",
+				statefulSet.Name)
+			return Requeue
+		}
 	} else {
+		rm.begin("deployment", "Deployment")
 		deployment, created, err := m.getOrCreateDeployment(
 			ctx, planner, destNamespace)
 		if err != nil {
@@ -275,19 +400,136 @@ func (m *SmbShareManager) Update(
 			return Result{err: err}
 		} else if resized {
 			m.logger.Info("Resized deployment")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonResizedDeployment,
+				"Resized deployment %s", deployment.Name)
+			return Requeue
+		}
+
+		changed, err := m.updateDebugLevels(
+			ctx, planner, deployment, &deployment.Spec.Template)
+		if err != nil {
+			return Result{err: err}
+		} else if changed {
+			m.logger.Info("Updated debug levels of deployment")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonUpdatedDebugLevels,
+				"Updated debug levels of deployment %s", deployment.Name)
+			return Requeue
+		}
+
+		changed, err = m.updateContainers(
+			ctx, planner, deployment, &deployment.Spec.Template)
+		if err != nil {
+			return Result{err: err}
+		} else if changed {
+			m.logger.Info("Updated containers of deployment")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonUpdatedContainers,
+				"Updated containers of deployment %s", deployment.Name)
+			return Requeue
+		}
+
+		changed, err = m.updateAuditLog(
+			ctx, planner, deployment, &deployment.Spec.Template)
+		if err != nil {
+			return Result{err: err}
+		} else if changed {
+			m.logger.Info("Updated audit log container of deployment")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonUpdatedAuditLog,
+				"Updated audit log container of deployment %s", deployment.Name)
+			return Requeue
+		}
+
+		changed, err = m.updatePasswordRotation(
+			ctx, planner, deployment, &deployment.Spec.Template)
+		if err != nil {
+			return Result{err: err}
+		} else if changed {
+			m.logger.Info("Rolled deployment for rotated passwords")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonRotatedPasswords,
+				"Restarted pods of deployment %s to apply rotated passwords",
+				deployment.Name)
 			return Requeue
 		}
 	}
 
-	_, created, err = m.getOrCreateService(
+	rm.begin("pod-disruption-budget", "PodDisruptionBudget")
+	pdb, created, err := m.getOrCreatePodDisruptionBudget(
+		ctx, planner, destNamespace)
+	if err != nil {
+		return Result{err: err}
+	} else if created {
+		m.logger.Info("Created pod disruption budget")
+		m.recorder.Eventf(instance,
+			EventNormal,
+			ReasonCreatedPodDisruptionBudget,
+			"Created pod disruption budget %s for SmbShare", pdb.Name)
+		return Requeue
+	}
+
+	changed, err = m.updatePodDisruptionBudget(ctx, planner, pdb)
+	if err != nil {
+		return Result{err: err}
+	} else if changed {
+		m.logger.Info("Updated pod disruption budget")
+		m.recorder.Eventf(instance,
+			EventNormal,
+			ReasonUpdatedPodDisruptionBudget,
+			"Updated pod disruption budget %s", pdb.Name)
+		return Requeue
+	}
+
+	rm.begin("service", "Service")
+	svc, created, err := m.getOrCreateService(
 		ctx, planner, destNamespace)
 	if err != nil {
 		return Result{err: err}
 	} else if created {
 		m.logger.Info("Created service")
+		m.recorder.Eventf(instance,
+			EventNormal,
+			ReasonCreatedService,
+			"Created service %s for SmbShare", svc.Name)
 		return Requeue
 	}
 
+	if planner.metricsEnabled() {
+		rm.begin("metrics-service", "Service")
+		svc, created, err = m.getOrCreateMetricsService(
+			ctx, planner, destNamespace)
+		if err != nil {
+			return Result{err: err}
+		} else if created {
+			m.logger.Info("Created metrics service")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonCreatedService,
+				"Created metrics service %s for SmbShare", svc.Name)
+			return Requeue
+		}
+	} else {
+		rm.begin("metrics-service", "Service")
+		deleted, err := m.deleteMetricsService(ctx, planner, destNamespace)
+		if err != nil {
+			return Result{err: err}
+		} else if deleted {
+			m.logger.Info("Deleted metrics service")
+			m.recorder.Eventf(instance,
+				EventNormal,
+				ReasonDeletedService,
+				"Deleted metrics service of SmbShare, metrics are disabled")
+			return Requeue
+		}
+	}
+
 	m.logger.Info("Done updating SmbShare resources")
 	return Done
 }
@@ -298,17 +540,29 @@ func (m *SmbShareManager) Finalize(
 	ctx context.Context,
 	instance *sambaoperatorv1alpha1.SmbShare) Result {
 	// ---
-	destNamespace := instance.Namespace
+	shareStates.set(instance, sharePhaseDeleting, "")
+	result := m.finalize(ctx, instance)
+	if err := result.Err(); err != nil {
+		m.recordFailure(instance, failureReason("finalize", err), err)
+	}
+	return result
+}
+
+func (m *SmbShareManager) finalize(
+	ctx context.Context,
+	instance *sambaoperatorv1alpha1.SmbShare) Result {
+	// ---
+	destNamespace := m.serverNamespace(instance)
 	cm, err := m.getConfigMap(ctx, instance, destNamespace)
-	if err == nil {
+	if err == nil && ownedBy(instance, cm) {
 		// previously, we kept one configmap for many SmbShares but have moved
 		// away from that however, just to be safe, we're retaining the finalizer
 		// and check that the config is OK to remove in the case that we need to
 		// share the config map across other/multiple resources in the future.
-		_, changed, err := m.updateConfiguration(ctx, cm, instance)
+		_, changes, err := m.updateConfiguration(ctx, cm, instance)
 		if err != nil {
 			return Result{err: err}
-		} else if changed {
+		} else if len(changes) > 0 {
 			m.logger.Info("Updated config map during Finalize")
 			return Requeue
 		}
@@ -316,12 +570,32 @@ func (m *SmbShareManager) Finalize(
 		return Result{err: err}
 	}
 
+	deleted, err := m.deleteOwnedResources(ctx, instance, destNamespace)
+	if err != nil {
+		return Result{err: err}
+	} else if deleted {
+		m.logger.Info("Deleted server resources during Finalize")
+		m.recorder.Eventf(instance,
+			EventNormal,
+			ReasonDeletedServerResources,
+			"Deleted resources of SmbShare in namespace %s", destNamespace)
+		return Requeue
+	}
+
 	m.logger.Info("Removing finalizer")
 	controllerutil.RemoveFinalizer(instance, shareFinalizer)
 	err = m.client.Update(ctx, instance)
 	if err != nil {
 		return Result{err: err}
 	}
+	shareStates.forget(types.NamespacedName{
+		Namespace: instance.Namespace,
+		Name:      instance.Name,
+	})
+	m.recorder.Event(instance,
+		EventNormal,
+		ReasonFinalized,
+		"Removed SmbShare configuration and finalizer")
 	return Done
 }
 
@@ -337,7 +611,7 @@ func (m *SmbShareManager) getOrCreateDeployment(
 	found := &appsv1.Deployment{}
 	err := m.client.Get(ctx, depKey, found)
 	if err == nil {
-		return found, false, nil
+		return found, false, m.checkOwner(planner.SmbShare, found)
 	}
 
 	if !errors.IsNotFound(err) {
@@ -356,9 +630,11 @@ func (m *SmbShareManager) getOrCreateDeployment(
 	// labels - do I need them?
 	dep := buildDeployment(
 		m.cfg, planner, planner.SmbShare.Spec.Storage.Pvc.Name, ns)
+	if err = m.checkPodNames(planner, &dep.Spec.Template.Spec); err != nil {
+		return nil, false, err
+	}
 	// set the smbshare instance as the owner and controller
-	err = controllerutil.SetControllerReference(
-		planner.SmbShare, dep, m.scheme)
+	err = m.setOwner(planner.SmbShare, dep)
 	if err != nil {
 		m.logger.Error(
 			err,
@@ -396,20 +672,10 @@ func (m *SmbShareManager) getOrCreateStatePVC(
 	ns string) (*corev1.PersistentVolumeClaim, bool, error) {
 	// ---
 	name := sharedStatePVCName(planner)
-	squant, err := kresource.ParseQuantity(
-		planner.GlobalConfig.StatePVCSize)
+	spec, err := planner.statePVCSpec()
 	if err != nil {
-		return nil, false, err
-	}
-	spec := &corev1.PersistentVolumeClaimSpec{
-		AccessModes: []corev1.PersistentVolumeAccessMode{
-			corev1.ReadWriteMany,
-		},
-		Resources: corev1.ResourceRequirements{
-			Requests: corev1.ResourceList{
-				corev1.ResourceStorage: squant,
-			},
-		},
+		m.logger.Error(err, "Invalid shared state PVC settings")
+		return nil, false, withReason(ReasonInvalidStatePVCSettings, err)
 	}
 	pvc, cr, err := m.getOrCreateGenericPVC(
 		ctx, planner.SmbShare, spec, name, ns)
@@ -421,19 +687,49 @@ func (m *SmbShareManager) getOrCreateStatePVC(
 
 func (m *SmbShareManager) getOrCreatePvc(
 	ctx context.Context,
-	smbShare *sambaoperatorv1alpha1.SmbShare,
+	planner *sharePlanner,
 	ns string) (*corev1.PersistentVolumeClaim, bool, error) {
 	// ---
-	name := pvcName(smbShare)
-	spec := smbShare.Spec.Storage.Pvc.Spec
+	name := pvcName(planner)
+	spec := planner.SmbShare.Spec.Storage.Pvc.Spec
 	pvc, cr, err := m.getOrCreateGenericPVC(
-		ctx, smbShare, spec, name, ns)
+		ctx, planner.SmbShare, spec, name, ns)
 	if err != nil {
 		m.logger.Error(err, "Error establishing data PVC")
 	}
 	return pvc, cr, err
 }
 
+// checkNamedPvc verifies that an existing PVC named by a share hosted in
+// the working namespace belongs to the share. Otherwise any share could
+// mount the data of the other shares, or any other PVC of the operator's
+// namespace, by naming it.
+func (m *SmbShareManager) checkNamedPvc(
+	ctx context.Context,
+	planner *sharePlanner,
+	ns string) error {
+	// ---
+	if !planner.centralized() {
+		return nil
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	pvcKey := types.NamespacedName{
+		Name:      planner.SmbShare.Spec.Storage.Pvc.Name,
+		Namespace: ns,
+	}
+	if err := m.client.Get(ctx, pvcKey, pvc); err != nil {
+		m.logger.Error(
+			err,
+			"Failed to get PVC",
+			"SmbShare.Namespace", planner.SmbShare.Namespace,
+			"SmbShare.Name", planner.SmbShare.Name,
+			"PersistentVolumeClaim.Namespace", pvcKey.Namespace,
+			"PersistentVolumeClaim.Name", pvcKey.Name)
+		return err
+	}
+	return m.checkOwner(planner.SmbShare, pvc)
+}
+
 func (m *SmbShareManager) getOrCreateGenericPVC(
 	ctx context.Context,
 	smbShare *sambaoperatorv1alpha1.SmbShare,
@@ -447,7 +743,7 @@ func (m *SmbShareManager) getOrCreateGenericPVC(
 	}
 	err := m.client.Get(ctx, pvcKey, pvc)
 	if err == nil {
-		return pvc, false, nil
+		return pvc, false, m.checkOwner(smbShare, pvc)
 	}
 
 	if !errors.IsNotFound(err) {
@@ -463,16 +759,9 @@ func (m *SmbShareManager) getOrCreateGenericPVC(
 	}
 
 	// not found - define a new pvc
-	pvc = &corev1.PersistentVolumeClaim{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: ns,
-		},
-		Spec: *spec,
-	}
+	pvc = newPVC(name, ns, spec)
 	// set the smb share instance as the owner and controller
-	err = controllerutil.SetControllerReference(
-		smbShare, pvc, m.scheme)
+	err = m.setOwner(smbShare, pvc)
 	if err != nil {
 		m.logger.Error(
 			err,
@@ -507,15 +796,61 @@ func (m *SmbShareManager) getOrCreateGenericPVC(
 func (m *SmbShareManager) getOrCreateService(
 	ctx context.Context, planner *sharePlanner, ns string) (
 	*corev1.Service, bool, error) {
+	// ---
+	return m.getOrCreateServiceFor(ctx, planner, newServiceForSmb(planner, ns))
+}
+
+func (m *SmbShareManager) getOrCreateMetricsService(
+	ctx context.Context, planner *sharePlanner, ns string) (
+	*corev1.Service, bool, error) {
+	// ---
+	return m.getOrCreateServiceFor(
+		ctx, planner, newMetricsServiceForSmb(planner, ns))
+}
+
+// deleteMetricsService deletes the metrics service of the server group, if
+// it exists. Only a service labeled as a metrics service is deleted.
+func (m *SmbShareManager) deleteMetricsService(
+	ctx context.Context, planner *sharePlanner, ns string) (bool, error) {
+	// ---
+	svc := &corev1.Service{}
+	svcKey := types.NamespacedName{
+		Name:      newMetricsServiceForSmb(planner, ns).Name,
+		Namespace: ns,
+	}
+	err := m.client.Get(ctx, svcKey, svc)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if svc.Labels[metricsLabel] != "true" || !ownedBy(planner.SmbShare, svc) {
+		return false, nil
+	}
+	m.logger.Info("Deleting metrics Service",
+		"Service.Namespace", svc.Namespace,
+		"Service.Name", svc.Name)
+	err = m.client.Delete(ctx, svc)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// getOrCreateServiceFor creates the given service if no service with the
+// same name exists.
+func (m *SmbShareManager) getOrCreateServiceFor(
+	ctx context.Context, planner *sharePlanner, svc *corev1.Service) (
+	*corev1.Service, bool, error) {
 	// Check if the service already exists, if not create a new one
 	found := &corev1.Service{}
 	svcKey := types.NamespacedName{
-		Name:      planner.instanceName(),
-		Namespace: ns,
+		Name:      svc.Name,
+		Namespace: svc.Namespace,
 	}
 	err := m.client.Get(ctx, svcKey, found)
 	if err == nil {
-		return found, false, nil
+		return found, false, m.checkOwner(planner.SmbShare, found)
 	}
 
 	if !errors.IsNotFound(err) {
@@ -530,11 +865,9 @@ func (m *SmbShareManager) getOrCreateService(
 		return nil, false, err
 	}
 
-	// not found - define a new deployment
-	svc := newServiceForSmb(planner, ns)
+	// not found - create the new service
 	// set the smbshare instance as the owner and controller
-	err = controllerutil.SetControllerReference(
-		planner.SmbShare, svc, m.scheme)
+	err = m.setOwner(planner.SmbShare, svc)
 	if err != nil {
 		m.logger.Error(
 			err,
@@ -561,7 +894,7 @@ func (m *SmbShareManager) getOrCreateService(
 			"Service.Name", svc.Name)
 		return svc, false, err
 	}
-	// Deployment created successfully
+	// Service created successfully
 	return svc, true, nil
 }
 
@@ -586,7 +919,7 @@ func (m *SmbShareManager) getOrCreateConfigMap(
 	}
 	err := m.client.Get(ctx, cmKey, found)
 	if err == nil {
-		return found, false, nil
+		return found, false, m.checkOwner(planner.SmbShare, found)
 	}
 
 	if !errors.IsNotFound(err) {
@@ -613,8 +946,7 @@ func (m *SmbShareManager) getOrCreateConfigMap(
 		return cm, false, err
 	}
 	// set the smbshare instance as the owner and controller
-	err = controllerutil.SetControllerReference(
-		planner.SmbShare, cm, m.scheme)
+	err = m.setOwner(planner.SmbShare, cm)
 	if err != nil {
 		m.logger.Error(
 			err,
@@ -652,7 +984,7 @@ func (m *SmbShareManager) getOrCreateStatefulSet(
 	}
 	err := m.client.Get(ctx, ssKey, found)
 	if err == nil {
-		return found, false, nil
+		return found, false, m.checkOwner(planner.SmbShare, found)
 	}
 
 	if !errors.IsNotFound(err) {
@@ -673,9 +1005,11 @@ func (m *SmbShareManager) getOrCreateStatefulSet(
 		planner.SmbShare.Spec.Storage.Pvc.Name,
 		sharedStatePVCName(planner),
 		ns)
+	if err = m.checkPodNames(planner, &ss.Spec.Template.Spec); err != nil {
+		return nil, false, err
+	}
 	// set the smbshare instance as the owner/controller
-	err = controllerutil.SetControllerReference(
-		planner.SmbShare, ss, m.scheme)
+	err = m.setOwner(planner.SmbShare, ss)
 	if err != nil {
 		m.logger.Error(
 			err,
@@ -707,6 +1041,91 @@ func (m *SmbShareManager) getOrCreateStatefulSet(
 	return ss, true, err
 }
 
+func (m *SmbShareManager) getOrCreatePodDisruptionBudget(
+	ctx context.Context,
+	planner *sharePlanner,
+	ns string) (*policyv1.PodDisruptionBudget, bool, error) {
+	// Check if the pdb already exists, if not create a new one
+	found := &policyv1.PodDisruptionBudget{}
+	pdbKey := types.NamespacedName{
+		Name:      planner.instanceName(),
+		Namespace: ns,
+	}
+	err := m.client.Get(ctx, pdbKey, found)
+	if err == nil {
+		return found, false, m.checkOwner(planner.SmbShare, found)
+	}
+
+	if !errors.IsNotFound(err) {
+		// unexpected error
+		m.logger.Error(
+			err,
+			"Failed to get PodDisruptionBudget",
+			"SmbShare.Namespace", planner.SmbShare.Namespace,
+			"SmbShare.Name", planner.SmbShare.Name,
+			"PodDisruptionBudget.Namespace", pdbKey.Namespace,
+			"PodDisruptionBudget.Name", pdbKey.Name)
+		return nil, false, err
+	}
+
+	// not found - define a new pod disruption budget
+	pdb := buildPodDisruptionBudget(planner, ns)
+	// set the smbshare instance as the owner/controller
+	err = m.setOwner(planner.SmbShare, pdb)
+	if err != nil {
+		m.logger.Error(
+			err,
+			"Failed to set controller reference",
+			"SmbShare.Namespace", planner.SmbShare.Namespace,
+			"SmbShare.Name", planner.SmbShare.Name,
+			"PodDisruptionBudget.Namespace", pdb.Namespace,
+			"PodDisruptionBudget.Name", pdb.Name)
+		return pdb, false, err
+	}
+	m.logger.Info(
+		"Creating a new PodDisruptionBudget",
+		"SmbShare.Namespace", planner.SmbShare.Namespace,
+		"SmbShare.Name", planner.SmbShare.Name,
+		"PodDisruptionBudget.Namespace", pdb.Namespace,
+		"PodDisruptionBudget.Name", pdb.Name,
+		"PodDisruptionBudget.MaxUnavailable", pdb.Spec.MaxUnavailable)
+	err = m.client.Create(ctx, pdb)
+	if err != nil {
+		m.logger.Error(
+			err,
+			"Failed to create new PodDisruptionBudget",
+			"SmbShare.Namespace", planner.SmbShare.Namespace,
+			"SmbShare.Name", planner.SmbShare.Name,
+			"PodDisruptionBudget.Namespace", pdb.Namespace,
+			"PodDisruptionBudget.Name", pdb.Name)
+		return pdb, false, err
+	}
+	return pdb, true, nil
+}
+
+func (m *SmbShareManager) updatePodDisruptionBudget(
+	ctx context.Context,
+	planner *sharePlanner,
+	pdb *policyv1.PodDisruptionBudget) (bool, error) {
+	// Ensure the budget matches the current configuration
+	maxUnavailable := planner.maxUnavailable()
+	current := pdb.Spec.MaxUnavailable
+	if current != nil && *current == maxUnavailable {
+		return false, nil
+	}
+	pdb.Spec.MaxUnavailable = &maxUnavailable
+	err := m.client.Update(ctx, pdb)
+	if err != nil {
+		m.logger.Error(
+			err,
+			"Failed to update PodDisruptionBudget",
+			"PodDisruptionBudget.Namespace", pdb.Namespace,
+			"PodDisruptionBudget.Name", pdb.Name)
+		return false, err
+	}
+	return true, nil
+}
+
 func (m *SmbShareManager) updateDeploymentSize(
 	ctx context.Context,
 	deployment *appsv1.Deployment) (bool, error) {
@@ -730,10 +1149,125 @@ func (m *SmbShareManager) updateDeploymentSize(
 	return false, nil
 }
 
-func pvcName(s *sambaoperatorv1alpha1.SmbShare) string {
+// recordFailure records a warning event for a failure to reconcile the
+// share. The messages do not vary between attempts so that the event
+// recorder can de-duplicate repeated failures. Conflicts are expected when
+// the share is updated concurrently and are retried without an event.
+func (m *SmbShareManager) recordFailure(
+	instance *sambaoperatorv1alpha1.SmbShare,
+	reason string,
+	err error) {
+	// ---
+	if errors.IsConflict(err) {
+		return
+	}
+	m.recorder.Event(instance, EventWarning, reason, err.Error())
+}
+
+// checkPodNames verifies that the user defined containers and volumes of
+// the pod spec are allowed and do not collide with those of the operator.
+func (m *SmbShareManager) checkPodNames(
+	planner *sharePlanner,
+	podSpec *corev1.PodSpec) error {
+	// ---
+	err := checkPodExtras(planner)
+	if err == nil {
+		err = checkPodNames(podSpec)
+	}
+	if err != nil {
+		m.logger.Error(
+			err,
+			"Invalid pod settings",
+			"SmbShare.Namespace", planner.SmbShare.Namespace,
+			"SmbShare.Name", planner.SmbShare.Name)
+		err = withReason(ReasonInvalidPodSettings, err)
+	}
+	return err
+}
+
+// updateDebugLevels ensures the debug levels of the samba daemons in the
+// pod template of obj match the current configuration. Changing the
+// template rolls the pods of the server group.
+func (m *SmbShareManager) updateDebugLevels(
+	ctx context.Context,
+	planner *sharePlanner,
+	obj rtclient.Object,
+	tmpl *corev1.PodTemplateSpec) (bool, error) {
+	// ---
+	if !syncDebugLevels(planner, tmpl) {
+		return false, nil
+	}
+	err := m.client.Update(ctx, obj)
+	if err != nil {
+		m.logger.Error(
+			err,
+			"Failed to update debug levels",
+			"Object.Namespace", obj.GetNamespace(),
+			"Object.Name", obj.GetName())
+		return false, err
+	}
+	return true, nil
+}
+
+// updateContainers ensures the images, image pull policies and resources of
+// the containers in the pod template of obj match the current
+// configuration. Changing the template rolls the pods of the server group.
+func (m *SmbShareManager) updateContainers(
+	ctx context.Context,
+	planner *sharePlanner,
+	obj rtclient.Object,
+	tmpl *corev1.PodTemplateSpec) (bool, error) {
+	// ---
+	if !syncContainers(planner, tmpl) {
+		return false, nil
+	}
+	err := m.client.Update(ctx, obj)
+	if err != nil {
+		m.logger.Error(
+			err,
+			"Failed to update containers",
+			"Object.Namespace", obj.GetNamespace(),
+			"Object.Name", obj.GetName())
+		return false, err
+	}
+	return true, nil
+}
+
+// updateAuditLog adds or removes the audit-log container of the pod
+// template of obj when auditing of the share is enabled or disabled.
+// Changing the template rolls the pods of the server group.
+func (m *SmbShareManager) updateAuditLog(
+	ctx context.Context,
+	planner *sharePlanner,
+	obj rtclient.Object,
+	tmpl *corev1.PodTemplateSpec) (bool, error) {
+	// ---
+	if !syncAuditLog(planner, tmpl) {
+		return false, nil
+	}
+	err := m.client.Update(ctx, obj)
+	if err != nil {
+		m.logger.Error(
+			err,
+			"Failed to update audit log container",
+			"Object.Namespace", obj.GetNamespace(),
+			"Object.Name", obj.GetName())
+		return false, err
+	}
+	return true, nil
+}
+
+// pvcName returns the name of the data PVC of the share. In the working
+// namespace generated names are derived from the server group, as shares
+// of different namespaces may have the same name.
+func pvcName(planner *sharePlanner) string {
+	s := planner.SmbShare
 	if s.Spec.Storage.Pvc.Name != "" {
 		return s.Spec.Storage.Pvc.Name
 	}
+	if planner.centralized() {
+		return planner.instanceName() + "-pvc"
+	}
 	return s.Name + "-pvc"
 }
 
@@ -745,15 +1279,19 @@ func shareNeedsPvc(s *sambaoperatorv1alpha1.SmbShare) bool {
 	return s.Spec.Storage.Pvc != nil && s.Spec.Storage.Pvc.Spec != nil
 }
 
+// updateConfiguration updates the container config stored in the ConfigMap
+// to include the share and returns the changes made to it. If the ConfigMap
+// was changed by another writer in the meantime, the changes are merged
+// into the latest version of the ConfigMap.
 func (m *SmbShareManager) updateConfiguration(
 	ctx context.Context,
 	cm *corev1.ConfigMap,
-	s *sambaoperatorv1alpha1.SmbShare) (*sharePlanner, bool, error) {
+	s *sambaoperatorv1alpha1.SmbShare) (*sharePlanner, smbcc.Diff, error) {
 	// extract config from map
-	cc, err := getContainerConfig(cm)
+	cc, storedVersion, err := getContainerConfig(cm)
 	if err != nil {
 		m.logger.Error(err, "unable to read samba container config")
-		return nil, false, err
+		return nil, nil, err
 	}
 	isDeleting := s.GetDeletionTimestamp() != nil
 	if isDeleting {
@@ -765,58 +1303,123 @@ func (m *SmbShareManager) updateConfiguration(
 				GlobalConfig: m.cfg,
 			},
 			nil)
-		return planner, false, nil
-	}
-	security, err := m.getSecurityConfig(ctx, s)
-	if err != nil {
-		m.logger.Error(err, "failed to get SmbSecurityConfig")
-		return nil, false, err
+		return planner, nil, nil
 	}
-	common, err := m.getCommonConfig(ctx, s)
+	base := cc.DeepCopy()
+	base.SCCVersion = storedVersion
+	planner, err := m.newPlanner(ctx, s, cc)
 	if err != nil {
-		m.logger.Error(err, "failed to get SmbCommonConfig")
-		return nil, false, err
+		return nil, nil, err
 	}
-
-	// extract config from map
-	var changed bool
-	planner := newSharePlanner(
-		InstanceConfiguration{
-			SmbShare:       s,
-			SecurityConfig: security,
-			CommonConfig:   common,
-			GlobalConfig:   m.cfg,
-		},
-		cc)
-	changed, err = planner.update()
-	if err != nil {
+	if _, err = planner.update(); err != nil {
 		m.logger.Error(err, "unable to update samba container config")
-		return nil, false, err
+		return nil, nil, err
 	}
-	if !changed {
+	changes := smbcc.Compare(base, planner.ConfigState)
+	if len(changes) == 0 {
 		// nothing changed between the planner and the config stored in the cm
 		// we can just return now as no changes need to be applied to the cm
-		return planner, false, nil
+		return planner, nil, nil
 	}
-	err = setContainerConfig(cm, planner.ConfigState)
-	if err != nil {
-		m.logger.Error(
-			err,
-			"unable to set container config in ConfigMap",
+	for _, c := range changes {
+		m.logger.Info("Changing samba container config",
 			"ConfigMap.Namespace", cm.Namespace,
-			"ConfigMap.Name", cm.Name)
-		return nil, false, err
+			"ConfigMap.Name", cm.Name,
+			"change", c.String())
+	}
+	err = m.storeContainerConfig(ctx, cm, planner.ConfigState)
+	if errors.IsConflict(err) {
+		err = m.mergeContainerConfig(ctx, cm, base, planner)
 	}
-	err = m.client.Update(ctx, cm)
 	if err != nil {
 		m.logger.Error(
 			err,
 			"failed to update ConfigMap",
 			"ConfigMap.Namespace", cm.Namespace,
 			"ConfigMap.Name", cm.Name)
-		return nil, false, err
+		return nil, nil, err
+	}
+	return planner, changes, nil
+}
+
+func (m *SmbShareManager) storeContainerConfig(
+	ctx context.Context,
+	cm *corev1.ConfigMap,
+	cc *smbcc.SambaContainerConfig) error {
+	// ---
+	if err := setContainerConfig(cm, cc); err != nil {
+		return err
+	}
+	return m.client.Update(ctx, cm)
+}
+
+// mergeContainerConfig applies the changes the planner made to the base
+// config to the latest version of the ConfigMap, preserving the changes
+// made by other writers.
+func (m *SmbShareManager) mergeContainerConfig(
+	ctx context.Context,
+	cm *corev1.ConfigMap,
+	base *smbcc.SambaContainerConfig,
+	planner *sharePlanner) error {
+	// ---
+	latest := &corev1.ConfigMap{}
+	if err := m.client.Get(ctx, rtclient.ObjectKeyFromObject(cm), latest); err != nil {
+		return err
+	}
+	theirs, _, err := getContainerConfig(latest)
+	if err != nil {
+		return err
+	}
+	merged, err := smbcc.Merge(base, planner.ConfigState, theirs)
+	if err != nil {
+		return err
+	}
+	// the config read back is migrated to the current version; it is
+	// always stored in the version supported by the images
+	merged.SCCVersion = planner.ConfigState.SCCVersion
+	m.logger.Info("Merging samba container config changed concurrently",
+		"ConfigMap.Namespace", cm.Namespace,
+		"ConfigMap.Name", cm.Name)
+	if err := m.storeContainerConfig(ctx, latest, merged); err != nil {
+		return err
+	}
+	planner.ConfigState = merged
+	latest.DeepCopyInto(cm)
+	return nil
+}
+
+// newPlanner returns a planner for the share and the configuration it
+// references, applied to the given container config.
+func (m *SmbShareManager) newPlanner(
+	ctx context.Context,
+	s *sambaoperatorv1alpha1.SmbShare,
+	cc *smbcc.SambaContainerConfig) (*sharePlanner, error) {
+	// ---
+	security, err := m.getSecurityConfig(ctx, s)
+	if err != nil {
+		m.logger.Error(err, "failed to get SmbSecurityConfig")
+		if errors.IsNotFound(err) {
+			err = withReason(ReasonMissingSecurityConfig, err)
+		}
+		return nil, err
+	}
+	common, err := m.getCommonConfig(ctx, s)
+	if err != nil {
+		m.logger.Error(err, "failed to get SmbCommonConfig")
+		if errors.IsNotFound(err) {
+			err = withReason(ReasonMissingCommonConfig, err)
+		}
+		return nil, err
 	}
-	return planner, true, nil
+	planner := newSharePlanner(
+		InstanceConfiguration{
+			SmbShare:       s,
+			SecurityConfig: security,
+			CommonConfig:   common,
+			GlobalConfig:   m.cfg,
+		},
+		cc)
+	return planner, nil
 }
 
 func (m *SmbShareManager) addFinalizer(
@@ -901,8 +1504,8 @@ func (m *SmbShareManager) setServerGroup(
 	}
 
 	// NOTE: currently the ServerGroup is only assigned the exact name of the
-	// resource. In the future this may change if/when multiple SmbShares can
-	// be hosted by one smbd pod.
-	s.Status.ServerGroup = s.ObjectMeta.Name
+	// resource, qualified by its namespace when needed. In the future this
+	// may change if/when multiple SmbShares can be hosted by one smbd pod.
+	s.Status.ServerGroup = m.serverGroupName(s)
 	return true, m.client.Status().Update(ctx, s)
 }
@@ -29,7 +29,11 @@ func buildStatefulSet(
 	size := planner.clusterSize()
 	podSpec := buildClusteredPodSpec(planner, dataPVCName, statePVCName)
 	if planner.nodeSpread() {
-		podSpec.Affinity = buildOneSmbdPerNodeAffinity(labels, serviceLabel)
+		podSpec.Affinity = withOneSmbdPerNodeAffinity(
+			podSpec.Affinity,
+			labels,
+			serviceLabel,
+			planner.nodeSpreadTopologyKey())
 	}
 	statefulSet := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -51,11 +55,36 @@ func buildStatefulSet(
 			},
 		},
 	}
+	setPasswordRotation(
+		&statefulSet.Spec.Template.ObjectMeta, planner.passwordRotation)
 	return statefulSet
 }
 
-func buildOneSmbdPerNodeAffinity(labels map[string]string, key string) *corev1.Affinity {
-	topoKey := "kubernetes.io/hostname"
+// withOneSmbdPerNodeAffinity returns an affinity that requires at most one
+// smbd pod per topology domain, preserving any rules already present in the
+// given affinity.
+func withOneSmbdPerNodeAffinity(
+	affinity *corev1.Affinity,
+	labels map[string]string,
+	key, topoKey string) *corev1.Affinity {
+	// ---
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	}
+	if affinity.PodAntiAffinity == nil {
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	paa := affinity.PodAntiAffinity
+	paa.RequiredDuringSchedulingIgnoredDuringExecution = append(
+		paa.RequiredDuringSchedulingIgnoredDuringExecution,
+		buildOneSmbdPerNodeAffinity(labels, key, topoKey).
+			PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+	return affinity
+}
+
+func buildOneSmbdPerNodeAffinity(
+	labels map[string]string, key, topoKey string) *corev1.Affinity {
+	// ---
 	value := labels[key]
 
 	aaTerms := []corev1.PodAffinityTerm{{
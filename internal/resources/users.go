@@ -0,0 +1,185 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+// hashUsersConfig converts the users config found in the users secret to
+// the config mounted in the pods, holding the users and groups with NT
+// hashes in place of plaintext passwords. The converted config is returned
+// both parsed and serialized. If plaintext passwords are rejected, an
+// error naming the users with a plaintext password is returned.
+func hashUsersConfig(
+	data []byte, policy plaintextPasswords, version string) (
+	*smbcc.SambaContainerConfig, []byte, error) {
+	// ---
+	cc, _, err := smbcc.Parse(data)
+	if err != nil {
+		return nil, nil, withReason(ReasonInvalidUsersSecret, err)
+	}
+	if version == smbcc.OldestVersion && cc.HasMemberships() {
+		return nil, nil, withReason(ReasonGroupMembershipsUnsupported, fmt.Errorf(
+			"users secret defines group memberships, which samba container "+
+				"config version %s does not support", version))
+	}
+	var plaintext []string
+	for _, entries := range cc.Users {
+		if policy == rejectPlaintextPasswords {
+			plaintext = append(plaintext, entries.PlaintextUsers()...)
+		} else {
+			entries.HashPasswords()
+		}
+	}
+	if len(plaintext) > 0 {
+		sort.Strings(plaintext)
+		return nil, nil, withReason(ReasonPlaintextPasswordsRejected, fmt.Errorf(
+			"users secret contains plaintext passwords for: %s",
+			strings.Join(plaintext, ", ")))
+	}
+	users := &smbcc.SambaContainerConfig{
+		SCCVersion: version,
+		Users:      cc.Users,
+		Groups:     cc.Groups,
+	}
+	b, err := json.Marshal(users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, b, nil
+}
+
+// updateUsersSecret creates or updates the secret holding the users config
+// mounted in the pods, derived from the users secret of the share's
+// security config. The password rotation recorded on the users secret is
+// carried over to the planner. It returns true if the secret was created
+// or updated.
+func (m *SmbShareManager) updateUsersSecret(
+	ctx context.Context,
+	planner *sharePlanner,
+	ns string) (bool, error) {
+	// ---
+	uss := planner.userSecuritySource()
+	if !uss.Configured {
+		return false, nil
+	}
+	src := &corev1.Secret{}
+	srcKey := types.NamespacedName{Namespace: uss.Namespace, Name: uss.Secret}
+	if err := m.client.Get(ctx, srcKey, src); err != nil {
+		m.logger.Error(err, "Failed to get users Secret",
+			"Secret.Namespace", srcKey.Namespace,
+			"Secret.Name", srcKey.Name)
+		if errors.IsNotFound(err) {
+			err = withReason(ReasonInvalidUsersSecret, err)
+		}
+		return false, err
+	}
+	rotation := src.Annotations[passwordRotationAnnotation]
+	planner.passwordRotation = rotation
+	data, found := src.Data[uss.Key]
+	if !found {
+		return false, withReason(ReasonInvalidUsersSecret, fmt.Errorf(
+			"users secret %s has no key %s", uss.Secret, uss.Key))
+	}
+	cc, users, err := hashUsersConfig(
+		data, uss.PlaintextPasswords, planner.containerConfigVersion())
+	if err != nil {
+		m.logger.Error(err, "Invalid users Secret",
+			"Secret.Namespace", srcKey.Namespace,
+			"Secret.Name", srcKey.Name)
+		return false, err
+	}
+	if problems := planner.accessProblems(cc); len(problems) > 0 {
+		return false, withReason(ReasonInvalidShareAccess, fmt.Errorf(
+			"share access list does not match users secret %s: %s",
+			uss.Secret, strings.Join(problems, ", ")))
+	}
+
+	dst := &corev1.Secret{}
+	dstKey := types.NamespacedName{Namespace: ns, Name: planner.usersSecretName()}
+	err = m.client.Get(ctx, dstKey, dst)
+	if errors.IsNotFound(err) {
+		dst = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      dstKey.Name,
+				Namespace: dstKey.Namespace,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{planner.usersConfigFileName(): users},
+		}
+		setPasswordRotation(&dst.ObjectMeta, rotation)
+		if err = m.setOwner(planner.SmbShare, dst); err != nil {
+			return false, err
+		}
+		m.logger.Info("Creating users Secret",
+			"Secret.Namespace", dst.Namespace,
+			"Secret.Name", dst.Name)
+		return true, m.client.Create(ctx, dst)
+	} else if err != nil {
+		return false, err
+	}
+	if err = m.checkOwner(planner.SmbShare, dst); err != nil {
+		return false, err
+	}
+	rotated := setPasswordRotation(&dst.ObjectMeta, rotation)
+	if bytes.Equal(dst.Data[planner.usersConfigFileName()], users) && !rotated {
+		return false, nil
+	}
+	dst.Data = map[string][]byte{planner.usersConfigFileName(): users}
+	m.logger.Info("Updating users Secret",
+		"Secret.Namespace", dst.Namespace,
+		"Secret.Name", dst.Name)
+	return true, m.client.Update(ctx, dst)
+}
+
+// updatePasswordRotation ensures the password rotation annotated on the
+// pod template of obj matches the one of the users secret. Changing the
+// template rolls the pods of the server group, so that they load the
+// rotated passwords.
+func (m *SmbShareManager) updatePasswordRotation(
+	ctx context.Context,
+	planner *sharePlanner,
+	obj rtclient.Object,
+	tmpl *corev1.PodTemplateSpec) (bool, error) {
+	// ---
+	if !setPasswordRotation(&tmpl.ObjectMeta, planner.passwordRotation) {
+		return false, nil
+	}
+	err := m.client.Update(ctx, obj)
+	if err != nil {
+		m.logger.Error(
+			err,
+			"Failed to update password rotation",
+			"Object.Namespace", obj.GetNamespace(),
+			"Object.Name", obj.GetName())
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,181 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/smbcc"
+)
+
+const usersJSON = `{
+  "samba-container-config": "v0",
+  "users": {
+    "all_entries": [
+      {"name": "alice", "password": "password"},
+      {"name": "bob", "nt_hash": "31D6CFE0D16AE931B73C59D7E0C089C0"}
+    ]
+  }
+}`
+
+func TestHashUsersConfig(t *testing.T) {
+	hashed, out, err := hashUsersConfig(
+		[]byte(usersJSON), convertPlaintextPasswords, smbcc.OldestVersion)
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), `"password"`)
+	cc, _, err := smbcc.Parse(out)
+	require.NoError(t, err)
+	assert.Equal(t, hashed.Users, cc.Users)
+	assert.Equal(t, smbcc.UserEntries{
+		{Name: "alice", NTHash: "8846F7EAEE8FB117AD06BDD830B7586C"},
+		{Name: "bob", NTHash: "31D6CFE0D16AE931B73C59D7E0C089C0"},
+	}, cc.Users[smbcc.AllEntriesKey])
+
+	_, _, err = hashUsersConfig(
+		[]byte(usersJSON), rejectPlaintextPasswords, smbcc.OldestVersion)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "alice")
+	assert.NotContains(t, err.Error(), "bob")
+	assert.Equal(t,
+		ReasonPlaintextPasswordsRejected,
+		failureReason("users-secret", err))
+
+	_, _, err = hashUsersConfig(
+		[]byte("users"), convertPlaintextPasswords, smbcc.OldestVersion)
+	assert.Equal(t,
+		ReasonInvalidUsersSecret,
+		failureReason("users-secret", err))
+
+	_, _, err = hashUsersConfig([]byte(`{
+  "samba-container-config": "v0",
+  "users": {"all_entries": [{"name": "alice", "groups": ["staff"]}]}
+}`), convertPlaintextPasswords, smbcc.OldestVersion)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `missing group "staff"`)
+	assert.Equal(t,
+		ReasonInvalidUsersSecret,
+		failureReason("users-secret", err))
+}
+
+func TestHashUsersConfigMemberships(t *testing.T) {
+	memberships := []byte(`{
+  "samba-container-config": "v1",
+  "users": {"all_entries": [{"name": "alice", "groups": ["staff"]}]},
+  "groups": {"all_entries": [{"name": "staff", "gid": 2000}]}
+}`)
+	_, _, err := hashUsersConfig(
+		memberships, convertPlaintextPasswords, smbcc.OldestVersion)
+	require.Error(t, err)
+	assert.Equal(t,
+		ReasonGroupMembershipsUnsupported,
+		failureReason("users-secret", err))
+
+	_, out, err := hashUsersConfig(
+		memberships, convertPlaintextPasswords, smbcc.CurrentVersion)
+	require.NoError(t, err)
+	cc, stored, err := smbcc.Parse(out)
+	require.NoError(t, err)
+	assert.Equal(t, smbcc.CurrentVersion, stored)
+	assert.Equal(t, []string{"alice"}, cc.GroupMembers("staff"))
+
+	// users without memberships are stored in the oldest version
+	_, out, err = hashUsersConfig(
+		[]byte(usersJSON), convertPlaintextPasswords, smbcc.OldestVersion)
+	require.NoError(t, err)
+	_, stored, err = smbcc.Parse(out)
+	require.NoError(t, err)
+	assert.Equal(t, smbcc.OldestVersion, stored)
+}
+
+func TestUpdateUsersSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, sambaoperatorv1alpha1.AddToScheme(scheme))
+	share := &sambaoperatorv1alpha1.SmbShare{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "utest", Name: "s1"},
+		Status:     sambaoperatorv1alpha1.SmbShareStatus{ServerGroup: "s1"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "utest", Name: "users"},
+		Data:       map[string][]byte{"demousers": []byte(usersJSON)},
+	}
+	m := &SmbShareManager{
+		client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(share, secret).
+			Build(),
+		scheme: scheme,
+		logger: logr.Discard(),
+		cfg:    &conf.OperatorConfig{},
+	}
+	planner := newSharePlanner(InstanceConfiguration{
+		SmbShare: share,
+		SecurityConfig: &sambaoperatorv1alpha1.SmbSecurityConfig{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "utest", Name: "sec"},
+			Spec: sambaoperatorv1alpha1.SmbSecurityConfigSpec{
+				Mode: "user",
+				Users: &sambaoperatorv1alpha1.SmbSecurityUsersSpec{
+					Secret: "users",
+					Key:    "demousers",
+				},
+			},
+		},
+		GlobalConfig: m.cfg,
+	}, smbcc.New())
+	ctx := context.TODO()
+
+	changed, err := m.updateUsersSecret(ctx, planner, "utest")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = m.updateUsersSecret(ctx, planner, "utest")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	derived := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: "utest", Name: "s1-smbusers"}
+	require.NoError(t, m.client.Get(ctx, key, derived))
+	assert.Len(t, derived.OwnerReferences, 1)
+	assert.Contains(t, string(derived.Data["users.json"]), "8846F7EA")
+	assert.NotContains(t, string(derived.Data["users.json"]), "password")
+
+	vol := userConfigVolumeAndMount(planner).volume
+	assert.Equal(t, "s1-smbusers", vol.Secret.SecretName)
+	assert.Equal(t, "users.json", vol.Secret.Items[0].Key)
+
+	share.Spec.Access = &sambaoperatorv1alpha1.SmbShareAccessSpec{
+		Users:  []string{"alice", "carol"},
+		Groups: []string{"staff"},
+	}
+	_, err = m.updateUsersSecret(ctx, planner, "utest")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown user "carol", unknown group "staff"`)
+	assert.Equal(t,
+		ReasonInvalidShareAccess,
+		failureReason("users-secret", err))
+}
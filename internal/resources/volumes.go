@@ -90,14 +90,13 @@ func configVolumeAndMount(planner *sharePlanner) volMount {
 func userConfigVolumeAndMount(planner *sharePlanner) volMount {
 	var vmnt volMount
 	// volume
-	uss := planner.userSecuritySource()
 	vmnt.volume = corev1.Volume{
 		Name: userSecretVolName,
 		VolumeSource: corev1.VolumeSource{
 			Secret: &corev1.SecretVolumeSource{
-				SecretName: uss.Secret,
+				SecretName: planner.usersSecretName(),
 				Items: []corev1.KeyToPath{{
-					Key:  uss.Key,
+					Key:  planner.usersConfigFileName(),
 					Path: planner.usersConfigFileName(),
 				}},
 			},
@@ -178,7 +177,7 @@ func joinJSONFileVolumeAndMount(planner *sharePlanner, index int) volMount {
 		Name: vname,
 		VolumeSource: corev1.VolumeSource{
 			Secret: &corev1.SecretVolumeSource{
-				SecretName: j.UserJoin.Secret,
+				SecretName: planner.podSecretName(j.UserJoin.Secret),
 				Items: []corev1.KeyToPath{{
 					Key:  j.UserJoin.Key,
 					Path: planner.joinJSONFileName(),
@@ -214,6 +213,24 @@ func svcWatchVolumeAndMount(dir string) volMount {
 	return vmnt
 }
 
+func auditVolumeAndMount(_ *sharePlanner) volMount {
+	var vmnt volMount
+	name := "samba-audit"
+	vmnt.volume = corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{
+				Medium: corev1.StorageMediumMemory,
+			},
+		},
+	}
+	vmnt.mount = corev1.VolumeMount{
+		MountPath: auditSocketDir,
+		Name:      name,
+	}
+	return vmnt
+}
+
 func ctdbConfigVolumeAndMount(_ *sharePlanner) volMount {
 	var vmnt volMount
 	name := "ctdb-config"
@@ -64,6 +64,8 @@ type UserEntry struct {
 	Gid      uint   `json:"gid,omitempty"`
 	NTHash   string `json:"nt_hash,omitempty"`
 	Password string `json:"password,omitempty"`
+	// Groups lists the names of the supplementary groups of the user.
+	Groups []string `json:"groups,omitempty"`
 }
 
 // UserEntries is a slice of UserEntry values.
@@ -73,6 +75,9 @@ type UserEntries []UserEntry
 type GroupEntry struct {
 	Name string `json:"name"`
 	Gid  uint   `json:"gid,omitempty"`
+	// Members lists the names of the users that are members of the group,
+	// in addition to the users naming the group in their own entry.
+	Members []string `json:"members,omitempty"`
 }
 
 // GroupEntries is a slice of GroupEntry values.
@@ -81,7 +86,12 @@ type GroupEntries []GroupEntry
 // SmbOptions is a common type for storing smb.conf parameters.
 type SmbOptions map[string]string
 
-const version0 = "v0"
+const (
+	version0 = "v0"
+	// version1 adds the supplementary groups of users and the members of
+	// groups.
+	version1 = "v1"
+)
 
 const (
 	// NoPrintingKey is used for the standard "noprinting" globals subsection.
@@ -104,13 +114,103 @@ const (
 // New returns a new samba container config.
 func New() *SambaContainerConfig {
 	return &SambaContainerConfig{
-		SCCVersion: version0,
+		SCCVersion: CurrentVersion,
 		Configs:    map[Key]ConfigSection{},
 		Shares:     map[Key]ShareConfig{},
 		Globals:    map[Key]GlobalConfig{},
 	}
 }
 
+// DeepCopy returns a copy of the config sharing no data with it.
+func (scc *SambaContainerConfig) DeepCopy() *SambaContainerConfig {
+	out := &SambaContainerConfig{SCCVersion: scc.SCCVersion}
+	if scc.Configs != nil {
+		out.Configs = map[Key]ConfigSection{}
+		for k, v := range scc.Configs {
+			out.Configs[k] = v.DeepCopy()
+		}
+	}
+	if scc.Shares != nil {
+		out.Shares = map[Key]ShareConfig{}
+		for k, v := range scc.Shares {
+			out.Shares[k] = ShareConfig{Options: v.Options.DeepCopy()}
+		}
+	}
+	if scc.Globals != nil {
+		out.Globals = map[Key]GlobalConfig{}
+		for k, v := range scc.Globals {
+			out.Globals[k] = GlobalConfig{Options: v.Options.DeepCopy()}
+		}
+	}
+	if scc.Users != nil {
+		out.Users = map[Key]UserEntries{}
+		for k, v := range scc.Users {
+			out.Users[k] = v.DeepCopy()
+		}
+	}
+	if scc.Groups != nil {
+		out.Groups = map[Key]GroupEntries{}
+		for k, v := range scc.Groups {
+			out.Groups[k] = v.DeepCopy()
+		}
+	}
+	return out
+}
+
+// DeepCopy returns a copy of the config section.
+func (cs ConfigSection) DeepCopy() ConfigSection {
+	out := cs
+	if cs.Shares != nil {
+		out.Shares = append(make([]Key, 0, len(cs.Shares)), cs.Shares...)
+	}
+	if cs.Globals != nil {
+		out.Globals = append(make([]Key, 0, len(cs.Globals)), cs.Globals...)
+	}
+	if cs.InstanceFeatures != nil {
+		out.InstanceFeatures = append(
+			make([]FeatureFlag, 0, len(cs.InstanceFeatures)),
+			cs.InstanceFeatures...)
+	}
+	return out
+}
+
+// DeepCopy returns a copy of the user entries.
+func (e UserEntries) DeepCopy() UserEntries {
+	out := append(make(UserEntries, 0, len(e)), e...)
+	for i := range out {
+		out[i].Groups = copyStrings(out[i].Groups)
+	}
+	return out
+}
+
+// DeepCopy returns a copy of the group entries.
+func (e GroupEntries) DeepCopy() GroupEntries {
+	out := append(make(GroupEntries, 0, len(e)), e...)
+	for i := range out {
+		out[i].Members = copyStrings(out[i].Members)
+	}
+	return out
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(s)), s...)
+}
+
+// DeepCopy returns a copy of the options.
+func (o SmbOptions) DeepCopy() SmbOptions {
+	if o == nil {
+		return nil
+	}
+	out := SmbOptions{}
+	for k, v := range o {
+		out[k] = v
+	}
+	return out
+}
+
 // NewNoPrintingGlobals returns a GlobalConfig that disables printing.
 func NewNoPrintingGlobals() GlobalConfig {
 	return GlobalConfig{
@@ -147,8 +247,8 @@ func NewConfigSection(name string) ConfigSection {
 func NewDefaultUsers() map[Key]UserEntries {
 	return map[Key]UserEntries{
 		AllEntriesKey: {{
-			Name:     "sambauser",
-			Password: "samba",
+			Name:   "sambauser",
+			NTHash: NTHash("samba"),
 		}},
 	}
 }
@@ -109,3 +109,32 @@ func TestMarshal(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, scc, scc2)
 }
+
+func TestSmbConf(t *testing.T) {
+	scc := New()
+	err := json.Unmarshal([]byte(json1), scc)
+	require.NoError(t, err)
+	text, err := scc.SmbConf("wbtest")
+	require.NoError(t, err)
+	require.Equal(t, `[global]
+	disable spoolss = yes
+	idmap config * : backend = autorid
+	idmap config * : range = 2000-9999999
+	load printers = no
+	log level = 10
+	netbios name = WB1
+	printcap name = /dev/null
+	printing = bsd
+	realm = FOOBAR.EXAMPLE.ORG
+	security = ads
+	server min protocol = SMB2
+	workgroup = FOOBAR
+
+[share]
+	path = /share
+	read only = no
+`, text)
+
+	_, err = scc.SmbConf("nope")
+	require.Error(t, err)
+}
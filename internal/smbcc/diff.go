@@ -0,0 +1,259 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbcc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind string
+
+const (
+	// Added means an entry or option was added.
+	Added ChangeKind = "added"
+	// Removed means an entry or option was removed.
+	Removed ChangeKind = "removed"
+	// Changed means the value of an entry or option was changed.
+	Changed ChangeKind = "changed"
+)
+
+// Section names the part of a config a Change applies to.
+type Section string
+
+const (
+	// VersionSection is the schema version of the config.
+	VersionSection Section = "version"
+	// ConfigsSection holds the ConfigSection entries.
+	ConfigsSection Section = "configs"
+	// SharesSection holds the ShareConfig entries.
+	SharesSection Section = "shares"
+	// GlobalsSection holds the GlobalConfig entries.
+	GlobalsSection Section = "globals"
+	// UsersSection holds the UserEntries entries.
+	UsersSection Section = "users"
+	// GroupsSection holds the GroupEntries entries.
+	GroupsSection Section = "groups"
+)
+
+// Change is a single difference between two configs. Shares and globals
+// are compared option by option; other entries are compared as a whole.
+type Change struct {
+	Kind    ChangeKind
+	Section Section
+	// Key is the key of the entry. It is empty for the version.
+	Key Key
+	// Option is the name of the changed option of a share or globals
+	// entry. It is empty if the entry as a whole was added or removed.
+	Option string
+	// Old and New are the values of a changed option or version.
+	Old, New string
+}
+
+func (c Change) String() string {
+	switch {
+	case c.Section == VersionSection:
+		return fmt.Sprintf("version changed from %q to %q", c.Old, c.New)
+	case c.Option == "":
+		return fmt.Sprintf("%s %q %s", c.Section, c.Key, c.Kind)
+	case c.Kind == Changed:
+		return fmt.Sprintf("%s %q option %q changed from %q to %q",
+			c.Section, c.Key, c.Option, c.Old, c.New)
+	}
+	return fmt.Sprintf("%s %q option %q %s", c.Section, c.Key, c.Option, c.Kind)
+}
+
+// Diff lists the changes between two configs.
+type Diff []Change
+
+// Summary describes at most max changes of the diff in a single line.
+func (d Diff) Summary(max int) string {
+	parts := []string{}
+	for i, c := range d {
+		if i == max {
+			parts = append(parts, fmt.Sprintf("and %d more", len(d)-max))
+			break
+		}
+		parts = append(parts, c.String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Compare returns the changes that turn the old config into the new one,
+// ordered by section and key. The values of user and group entries are
+// not included in the changes, as they may hold passwords.
+func Compare(old, new *SambaContainerConfig) Diff {
+	var d Diff
+	if old.SCCVersion != new.SCCVersion {
+		d = append(d, Change{
+			Kind:    Changed,
+			Section: VersionSection,
+			Old:     old.SCCVersion,
+			New:     new.SCCVersion,
+		})
+	}
+	d = append(d, compareEntries(ConfigsSection,
+		configEntries(old.Configs), configEntries(new.Configs))...)
+	d = append(d, compareOptions(SharesSection,
+		shareOptions(old.Shares), shareOptions(new.Shares))...)
+	d = append(d, compareOptions(GlobalsSection,
+		globalOptions(old.Globals), globalOptions(new.Globals))...)
+	d = append(d, compareEntries(UsersSection,
+		userEntries(old.Users), userEntries(new.Users))...)
+	d = append(d, compareEntries(GroupsSection,
+		groupEntries(old.Groups), groupEntries(new.Groups))...)
+	return d
+}
+
+func configEntries(m map[Key]ConfigSection) map[Key]interface{} {
+	out := map[Key]interface{}{}
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func userEntries(m map[Key]UserEntries) map[Key]interface{} {
+	out := map[Key]interface{}{}
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func groupEntries(m map[Key]GroupEntries) map[Key]interface{} {
+	out := map[Key]interface{}{}
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func shareOptions(m map[Key]ShareConfig) map[Key]SmbOptions {
+	out := map[Key]SmbOptions{}
+	for k, v := range m {
+		out[k] = v.Options
+	}
+	return out
+}
+
+func globalOptions(m map[Key]GlobalConfig) map[Key]SmbOptions {
+	out := map[Key]SmbOptions{}
+	for k, v := range m {
+		out[k] = v.Options
+	}
+	return out
+}
+
+func sortedKeys(sets ...map[Key]bool) []Key {
+	all := map[Key]bool{}
+	for _, s := range sets {
+		for k := range s {
+			all[k] = true
+		}
+	}
+	keys := make([]Key, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func compareEntries(
+	section Section, old, new map[Key]interface{}) Diff {
+	// ---
+	var d Diff
+	for _, k := range sortedKeys(keySet(old), keySet(new)) {
+		ov, inOld := old[k]
+		nv, inNew := new[k]
+		switch {
+		case !inOld:
+			d = append(d, Change{Kind: Added, Section: section, Key: k})
+		case !inNew:
+			d = append(d, Change{Kind: Removed, Section: section, Key: k})
+		case !reflect.DeepEqual(ov, nv):
+			d = append(d, Change{Kind: Changed, Section: section, Key: k})
+		}
+	}
+	return d
+}
+
+func compareOptions(
+	section Section, old, new map[Key]SmbOptions) Diff {
+	// ---
+	var d Diff
+	for _, k := range sortedKeys(optionKeySet(old), optionKeySet(new)) {
+		oo, inOld := old[k]
+		no, inNew := new[k]
+		switch {
+		case !inOld:
+			d = append(d, Change{Kind: Added, Section: section, Key: k})
+			continue
+		case !inNew:
+			d = append(d, Change{Kind: Removed, Section: section, Key: k})
+			continue
+		}
+		names := map[string]bool{}
+		for n := range oo {
+			names[n] = true
+		}
+		for n := range no {
+			names[n] = true
+		}
+		sorted := make([]string, 0, len(names))
+		for n := range names {
+			sorted = append(sorted, n)
+		}
+		sort.Strings(sorted)
+		for _, n := range sorted {
+			ov, inOld := oo[n]
+			nv, inNew := no[n]
+			c := Change{Section: section, Key: k, Option: n, Old: ov, New: nv}
+			switch {
+			case !inOld:
+				c.Kind = Added
+			case !inNew:
+				c.Kind = Removed
+			case ov != nv:
+				c.Kind = Changed
+			default:
+				continue
+			}
+			d = append(d, c)
+		}
+	}
+	return d
+}
+
+func keySet(m map[Key]interface{}) map[Key]bool {
+	s := map[Key]bool{}
+	for k := range m {
+		s[k] = true
+	}
+	return s
+}
+
+func optionKeySet(m map[Key]SmbOptions) map[Key]bool {
+	s := map[Key]bool{}
+	for k := range m {
+		s[k] = true
+	}
+	return s
+}
@@ -0,0 +1,132 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbcc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func baseConfig() *SambaContainerConfig {
+	scc := New()
+	scc.Globals[NoPrintingKey] = NewNoPrintingGlobals()
+	scc.Shares["share"] = NewSimpleShare("/share")
+	cfg := NewConfigSection("WB1")
+	cfg.Shares = []Key{"share"}
+	cfg.Globals = []Key{NoPrintingKey}
+	scc.Configs["wb1"] = cfg
+	scc.Users = NewDefaultUsers()
+	return scc
+}
+
+func TestDeepCopy(t *testing.T) {
+	scc := baseConfig()
+	c := scc.DeepCopy()
+	require.Equal(t, scc, c)
+	c.Shares["share"].Options["path"] = "/other"
+	c.Configs["wb1"].Shares[0] = "other"
+	c.Users[AllEntriesKey][0].NTHash = "secret"
+	assert.Equal(t, "/share", scc.Shares["share"].Options["path"])
+	assert.Equal(t, Key("share"), scc.Configs["wb1"].Shares[0])
+	assert.Equal(t, NTHash("samba"), scc.Users[AllEntriesKey][0].NTHash)
+}
+
+func TestCompare(t *testing.T) {
+	old := baseConfig()
+	assert.Len(t, Compare(old, old.DeepCopy()), 0)
+
+	new := old.DeepCopy()
+	new.Shares["share"].Options[ReadOnlyParam] = Yes
+	new.Shares["share"].Options[BrowseableParam] = No
+	delete(new.Shares["share"].Options, "path")
+	new.Shares["extra"] = NewSimpleShare("/extra")
+	delete(new.Globals, NoPrintingKey)
+	cfg := new.Configs["wb1"]
+	cfg.InstanceName = "WB2"
+	new.Configs["wb1"] = cfg
+	new.Users[AllEntriesKey][0].Password = "secret"
+
+	d := Compare(old, new)
+	assert.Equal(t, Diff{
+		{Kind: Changed, Section: ConfigsSection, Key: "wb1"},
+		{Kind: Added, Section: SharesSection, Key: "extra"},
+		{Kind: Added, Section: SharesSection, Key: "share",
+			Option: BrowseableParam, New: No},
+		{Kind: Removed, Section: SharesSection, Key: "share",
+			Option: "path", Old: "/share"},
+		{Kind: Changed, Section: SharesSection, Key: "share",
+			Option: ReadOnlyParam, Old: No, New: Yes},
+		{Kind: Removed, Section: GlobalsSection, Key: NoPrintingKey},
+		{Kind: Changed, Section: UsersSection, Key: AllEntriesKey},
+	}, d)
+	assert.Equal(t,
+		`configs "wb1" changed; shares "extra" added; `+
+			`shares "share" option "browseable" added; and 4 more`,
+		d.Summary(3))
+	assert.NotContains(t, d.Summary(10), "secret")
+	assert.Contains(t, d.Summary(10),
+		`shares "share" option "read only" changed from "no" to "yes"`)
+}
+
+func TestMerge(t *testing.T) {
+	base := baseConfig()
+
+	ours := base.DeepCopy()
+	ours.Shares["share"].Options[ReadOnlyParam] = Yes
+	ours.Shares["ours"] = NewSimpleShare("/ours")
+
+	theirs := base.DeepCopy()
+	theirs.Shares["share"].Options["comment"] = "theirs"
+	theirs.Shares["theirs"] = NewSimpleShare("/theirs")
+	theirs.Groups = map[Key]GroupEntries{AllEntriesKey: {{Name: "staff"}}}
+
+	merged, err := Merge(base, ours, theirs)
+	require.NoError(t, err)
+	assert.Equal(t, Yes, merged.Shares["share"].Options[ReadOnlyParam])
+	assert.Equal(t, "theirs", merged.Shares["share"].Options["comment"])
+	assert.Contains(t, merged.Shares, Key("ours"))
+	assert.Contains(t, merged.Shares, Key("theirs"))
+	assert.Len(t, merged.Groups, 1)
+	// the inputs are not modified
+	assert.NotContains(t, theirs.Shares, Key("ours"))
+
+	// the same change on both sides is not a conflict
+	theirs.Shares["share"].Options[ReadOnlyParam] = Yes
+	_, err = Merge(base, ours, theirs)
+	require.NoError(t, err)
+
+	theirs.Shares["share"].Options[ReadOnlyParam] = "maybe"
+	_, err = Merge(base, ours, theirs)
+	var merr *MergeConflictError
+	require.True(t, errors.As(err, &merr))
+	assert.Equal(t, Diff{{
+		Kind:    Changed,
+		Section: SharesSection,
+		Key:     "share",
+		Option:  ReadOnlyParam,
+		Old:     No,
+		New:     Yes,
+	}}, merr.Conflicts)
+
+	// options can not be merged into an entry removed by theirs
+	theirs = base.DeepCopy()
+	delete(theirs.Shares, "share")
+	_, err = Merge(base, ours, theirs)
+	assert.True(t, errors.As(err, &merr))
+}
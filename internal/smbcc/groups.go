@@ -0,0 +1,160 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbcc
+
+import (
+	"fmt"
+	"sort"
+)
+
+// HasUser returns true if a user with the given name is defined in any
+// users subsection.
+func (scc *SambaContainerConfig) HasUser(name string) bool {
+	for _, entries := range scc.Users {
+		for _, u := range entries {
+			if u.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasGroup returns true if a group with the given name is defined in any
+// groups subsection.
+func (scc *SambaContainerConfig) HasGroup(name string) bool {
+	for _, entries := range scc.Groups {
+		for _, g := range entries {
+			if g.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasMemberships returns true if any user lists supplementary groups or
+// any group lists members.
+func (scc *SambaContainerConfig) HasMemberships() bool {
+	for _, entries := range scc.Users {
+		for _, u := range entries {
+			if len(u.Groups) > 0 {
+				return true
+			}
+		}
+	}
+	for _, entries := range scc.Groups {
+		for _, g := range entries {
+			if len(g.Members) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GroupMembers returns the sorted names of the members of a group, both
+// those listed by the group and those listing the group among their
+// supplementary groups.
+func (scc *SambaContainerConfig) GroupMembers(group string) []string {
+	seen := map[string]bool{}
+	for _, entries := range scc.Groups {
+		for _, g := range entries {
+			if g.Name != group {
+				continue
+			}
+			for _, m := range g.Members {
+				seen[m] = true
+			}
+		}
+	}
+	for _, entries := range scc.Users {
+		for _, u := range entries {
+			for _, g := range u.Groups {
+				if g == group {
+					seen[u.Name] = true
+				}
+			}
+		}
+	}
+	members := make([]string, 0, len(seen))
+	for m := range seen {
+		members = append(members, m)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// userProblems checks that user and group names are set and unique within
+// each subsection, and that group memberships only reference users and
+// groups of the matching subsection.
+func (scc *SambaContainerConfig) userProblems() []string {
+	var problems []string
+	users := map[Key]map[string]bool{}
+	for _, k := range sortedKeys(keySet(userEntries(scc.Users))) {
+		users[k] = map[string]bool{}
+		for _, u := range scc.Users[k] {
+			if p := checkName("user", k, u.Name, users[k]); p != "" {
+				problems = append(problems, p)
+			}
+		}
+	}
+	groups := map[Key]map[string]bool{}
+	for _, k := range sortedKeys(keySet(groupEntries(scc.Groups))) {
+		groups[k] = map[string]bool{}
+		for _, g := range scc.Groups[k] {
+			if p := checkName("group", k, g.Name, groups[k]); p != "" {
+				problems = append(problems, p)
+			}
+		}
+	}
+	for _, k := range sortedKeys(keySet(userEntries(scc.Users))) {
+		for _, u := range scc.Users[k] {
+			for _, g := range u.Groups {
+				if !groups[k][g] {
+					problems = append(problems, fmt.Sprintf(
+						"user %q in %q is a member of missing group %q",
+						u.Name, k, g))
+				}
+			}
+		}
+	}
+	for _, k := range sortedKeys(keySet(groupEntries(scc.Groups))) {
+		for _, g := range scc.Groups[k] {
+			for _, m := range g.Members {
+				if !users[k][m] {
+					problems = append(problems, fmt.Sprintf(
+						"group %q in %q has missing member %q",
+						g.Name, k, m))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// checkName records a user or group name as seen, returning a problem if
+// the name is empty or was already seen.
+func checkName(kind string, k Key, name string, seen map[string]bool) string {
+	switch {
+	case name == "":
+		return fmt.Sprintf("%s without a name in %q", kind, k)
+	case seen[name]:
+		return fmt.Sprintf("duplicate %s %q in %q", kind, name, k)
+	}
+	seen[name] = true
+	return ""
+}
@@ -0,0 +1,98 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbcc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const groupsJSON = `{
+  "samba-container-config": "v1",
+  "users": {
+    "all_entries": [
+      {"name": "alice", "password": "a", "groups": ["staff"]},
+      {"name": "bob", "password": "b"}
+    ]
+  },
+  "groups": {
+    "all_entries": [
+      {"name": "staff", "gid": 2000, "members": ["bob"]},
+      {"name": "admins", "gid": 2001}
+    ]
+  }
+}`
+
+func TestGroupMembership(t *testing.T) {
+	cc, _, err := Parse([]byte(groupsJSON))
+	require.NoError(t, err)
+	assert.True(t, cc.HasUser("alice"))
+	assert.False(t, cc.HasUser("staff"))
+	assert.True(t, cc.HasGroup("admins"))
+	assert.False(t, cc.HasGroup("bob"))
+	assert.Equal(t, []string{"alice", "bob"}, cc.GroupMembers("staff"))
+	assert.Empty(t, cc.GroupMembers("admins"))
+
+	cp := cc.DeepCopy()
+	cp.Users[AllEntriesKey][0].Groups[0] = "admins"
+	cp.Groups[AllEntriesKey][0].Members[0] = "alice"
+	assert.Equal(t, []string{"alice", "bob"}, cc.GroupMembers("staff"))
+}
+
+func TestValidateUsers(t *testing.T) {
+	cc, _, err := Parse([]byte(groupsJSON))
+	require.NoError(t, err)
+	cc.Users[AllEntriesKey] = append(cc.Users[AllEntriesKey],
+		UserEntry{Name: "bob"},
+		UserEntry{Name: "carol", Groups: []string{"ops"}},
+		UserEntry{})
+	cc.Groups[AllEntriesKey][1].Members = []string{"dave"}
+	err = cc.Validate()
+	var invalid *InvalidConfigError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []string{
+		`duplicate user "bob" in "all_entries"`,
+		`user without a name in "all_entries"`,
+		`user "carol" in "all_entries" is a member of missing group "ops"`,
+		`group "admins" in "all_entries" has missing member "dave"`,
+	}, invalid.Problems)
+
+	// memberships do not cross subsections
+	cc, _, err = Parse([]byte(groupsJSON))
+	require.NoError(t, err)
+	cc.Groups = map[Key]GroupEntries{"other": cc.Groups[AllEntriesKey]}
+	assert.Error(t, cc.Validate())
+}
+
+func TestMembershipsVersion(t *testing.T) {
+	cc, stored, err := Parse([]byte(groupsJSON))
+	require.NoError(t, err)
+	assert.Equal(t, version1, stored)
+	assert.True(t, cc.HasMemberships())
+
+	// v0 can not store memberships
+	cc.SCCVersion = OldestVersion
+	err = cc.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "group memberships require version")
+
+	cc.Users[AllEntriesKey][0].Groups = nil
+	cc.Groups[AllEntriesKey][0].Members = nil
+	assert.False(t, cc.HasMemberships())
+	assert.NoError(t, cc.Validate())
+}
@@ -0,0 +1,176 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbcc
+
+import (
+	"reflect"
+)
+
+// MergeConflictError is returned by Merge when both sides changed the same
+// entry or option differently.
+type MergeConflictError struct {
+	// Conflicts are the changes of ours that conflict with theirs.
+	Conflicts Diff
+}
+
+func (e *MergeConflictError) Error() string {
+	return "conflicting samba container config changes: " +
+		e.Conflicts.Summary(5)
+}
+
+// Merge performs a three-way merge of two configs derived from a common
+// base config. The changes between base and ours are applied to theirs, so
+// that entries added or changed only in theirs are preserved. If theirs
+// changed an entry or option that ours also changed, to a different value,
+// a MergeConflictError is returned.
+func Merge(base, ours, theirs *SambaContainerConfig) (
+	*SambaContainerConfig, error) {
+	// ---
+	result := theirs.DeepCopy()
+	var conflicts Diff
+	for _, c := range Compare(base, ours) {
+		baseValue, _ := lookup(base, c)
+		oursValue, _ := lookup(ours, c)
+		theirsValue, _ := lookup(theirs, c)
+		conflict := !reflect.DeepEqual(theirsValue, baseValue) &&
+			!reflect.DeepEqual(theirsValue, oursValue)
+		if c.Option != "" {
+			// options can only be merged into an existing entry
+			_, found := lookup(result, Change{Section: c.Section, Key: c.Key})
+			conflict = conflict || !found
+		}
+		if conflict {
+			conflicts = append(conflicts, c)
+			continue
+		}
+		apply(result, ours, c)
+	}
+	if len(conflicts) > 0 {
+		return nil, &MergeConflictError{Conflicts: conflicts}
+	}
+	return result, nil
+}
+
+// lookup returns the value of the entry or option a change applies to.
+func lookup(scc *SambaContainerConfig, c Change) (interface{}, bool) {
+	var (
+		v     interface{}
+		found bool
+	)
+	switch c.Section {
+	case VersionSection:
+		return scc.SCCVersion, true
+	case ConfigsSection:
+		v, found = scc.Configs[c.Key]
+	case UsersSection:
+		v, found = scc.Users[c.Key]
+	case GroupsSection:
+		v, found = scc.Groups[c.Key]
+	case SharesSection:
+		var e ShareConfig
+		e, found = scc.Shares[c.Key]
+		v = e.Options
+	case GlobalsSection:
+		var e GlobalConfig
+		e, found = scc.Globals[c.Key]
+		v = e.Options
+	}
+	if !found || c.Option == "" {
+		return v, found
+	}
+	opt, found := v.(SmbOptions)[c.Option]
+	if !found {
+		return nil, false
+	}
+	return opt, true
+}
+
+// apply makes the entry or option of dst a change applies to equal to
+// that of src.
+func apply(dst, src *SambaContainerConfig, c Change) {
+	if c.Section == VersionSection {
+		dst.SCCVersion = src.SCCVersion
+		return
+	}
+	if c.Option != "" {
+		var opts SmbOptions
+		switch c.Section {
+		case SharesSection:
+			if opts = dst.Shares[c.Key].Options; opts == nil {
+				opts = SmbOptions{}
+				dst.Shares[c.Key] = ShareConfig{Options: opts}
+			}
+		case GlobalsSection:
+			if opts = dst.Globals[c.Key].Options; opts == nil {
+				opts = SmbOptions{}
+				dst.Globals[c.Key] = GlobalConfig{Options: opts}
+			}
+		}
+		if c.Kind == Removed {
+			delete(opts, c.Option)
+		} else {
+			opts[c.Option] = c.New
+		}
+		return
+	}
+	switch c.Section {
+	case ConfigsSection:
+		if c.Kind == Removed {
+			delete(dst.Configs, c.Key)
+		} else {
+			if dst.Configs == nil {
+				dst.Configs = map[Key]ConfigSection{}
+			}
+			dst.Configs[c.Key] = src.DeepCopy().Configs[c.Key]
+		}
+	case SharesSection:
+		if c.Kind == Removed {
+			delete(dst.Shares, c.Key)
+		} else {
+			if dst.Shares == nil {
+				dst.Shares = map[Key]ShareConfig{}
+			}
+			dst.Shares[c.Key] = src.DeepCopy().Shares[c.Key]
+		}
+	case GlobalsSection:
+		if c.Kind == Removed {
+			delete(dst.Globals, c.Key)
+		} else {
+			if dst.Globals == nil {
+				dst.Globals = map[Key]GlobalConfig{}
+			}
+			dst.Globals[c.Key] = src.DeepCopy().Globals[c.Key]
+		}
+	case UsersSection:
+		if c.Kind == Removed {
+			delete(dst.Users, c.Key)
+		} else {
+			if dst.Users == nil {
+				dst.Users = map[Key]UserEntries{}
+			}
+			dst.Users[c.Key] = src.DeepCopy().Users[c.Key]
+		}
+	case GroupsSection:
+		if c.Kind == Removed {
+			delete(dst.Groups, c.Key)
+		} else {
+			if dst.Groups == nil {
+				dst.Groups = map[Key]GroupEntries{}
+			}
+			dst.Groups[c.Key] = src.DeepCopy().Groups[c.Key]
+		}
+	}
+}
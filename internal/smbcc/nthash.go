@@ -0,0 +1,69 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbcc
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// NTHash returns the NT hash of a password, as used by samba: the MD4
+// digest of the UTF-16LE encoded password, in upper case hexadecimal.
+func NTHash(password string) string {
+	units := utf16.Encode([]rune(password))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[2*i:], u)
+	}
+	// MD4 is broken and only used because samba requires NT hashes.
+	h := md4.New()
+	h.Write(b)
+	return strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+}
+
+// HashPasswords replaces the plaintext password of each user with its NT
+// hash. Users that already have a NT hash keep it. It returns the names of
+// the users whose password was converted.
+func (e UserEntries) HashPasswords() []string {
+	var converted []string
+	for i := range e {
+		if e[i].Password == "" {
+			continue
+		}
+		if e[i].NTHash == "" {
+			e[i].NTHash = NTHash(e[i].Password)
+		}
+		e[i].Password = ""
+		converted = append(converted, e[i].Name)
+	}
+	return converted
+}
+
+// PlaintextUsers returns the names of the users with a plaintext
+// password.
+func (e UserEntries) PlaintextUsers() []string {
+	var names []string
+	for _, u := range e {
+		if u.Password != "" {
+			names = append(names, u.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,39 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbcc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNTHash(t *testing.T) {
+	assert.Equal(t, "8846F7EAEE8FB117AD06BDD830B7586C", NTHash("password"))
+	assert.Equal(t, "31D6CFE0D16AE931B73C59D7E0C089C0", NTHash(""))
+
+	users := UserEntries{
+		{Name: "alice", Password: "password"},
+		{Name: "bob", NTHash: "31D6CFE0D16AE931B73C59D7E0C089C0"},
+	}
+	assert.Equal(t, []string{"alice"}, users.PlaintextUsers())
+	assert.Equal(t, []string{"alice"}, users.HashPasswords())
+	assert.Equal(t, UserEntries{
+		{Name: "alice", NTHash: "8846F7EAEE8FB117AD06BDD830B7586C"},
+		{Name: "bob", NTHash: "31D6CFE0D16AE931B73C59D7E0C089C0"},
+	}, users)
+	assert.Len(t, users.PlaintextUsers(), 0)
+}
@@ -0,0 +1,74 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbcc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// netbiosNameParam is set to the instance name of a config, as done by
+// sambacc when it applies the config.
+const netbiosNameParam = "netbios name"
+
+// SmbConf returns the smb.conf text equivalent to the config section with
+// the given key. Globals are merged in the order they are listed, later
+// values replacing earlier ones, and parameters are sorted by name.
+func (scc *SambaContainerConfig) SmbConf(key Key) (string, error) {
+	section, found := scc.Configs[key]
+	if !found {
+		return "", fmt.Errorf("no config %q in samba container config", key)
+	}
+	globals := SmbOptions{}
+	for _, g := range section.Globals {
+		gc, found := scc.Globals[g]
+		if !found {
+			return "", fmt.Errorf(
+				"config %q references missing globals %q", key, g)
+		}
+		for k, v := range gc.Options {
+			globals[k] = v
+		}
+	}
+	if section.InstanceName != "" {
+		globals[netbiosNameParam] = section.InstanceName
+	}
+	sb := &strings.Builder{}
+	writeSmbConfSection(sb, "global", globals)
+	for _, s := range section.Shares {
+		sc, found := scc.Shares[s]
+		if !found {
+			return "", fmt.Errorf(
+				"config %q references missing share %q", key, s)
+		}
+		sb.WriteString("\n")
+		writeSmbConfSection(sb, string(s), sc.Options)
+	}
+	return sb.String(), nil
+}
+
+func writeSmbConfSection(sb *strings.Builder, name string, opts SmbOptions) {
+	fmt.Fprintf(sb, "[%s]\n", name)
+	names := make([]string, 0, len(opts))
+	for k := range opts {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		fmt.Fprintf(sb, "\t%s = %s\n", k, opts[k])
+	}
+}
@@ -0,0 +1,214 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbcc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CurrentVersion is the schema version of the configs produced by this
+// package.
+const CurrentVersion = version1
+
+// OldestVersion is the oldest schema version a config can be stored in.
+// Samba container images support it even if they do not support the
+// current version.
+const OldestVersion = version0
+
+// IsKnownVersion returns true if configs can be stored in the schema
+// version.
+func IsKnownVersion(v string) bool {
+	return v == version0 || v == version1
+}
+
+// versionKey is the key of the schema version in a serialized config.
+const versionKey = "samba-container-config"
+
+// versionUnset is the version of configs lacking a version key.
+const versionUnset = ""
+
+// rawConfig is a serialized config, of any version, decoded generically
+// so that it can be migrated before being decoded into the current types.
+type rawConfig map[string]interface{}
+
+// migration converts a raw config from one schema version to the next.
+type migration struct {
+	from, to string
+	apply    func(rawConfig) error
+}
+
+// migrations lists the conversions from each older version, applied in
+// sequence until a config reaches the current version.
+var migrations = []migration{
+	{from: versionUnset, to: version0, apply: migrateUnsetToV0},
+	{from: version0, to: version1, apply: migrateV0ToV1},
+}
+
+// migrateUnsetToV0 converts configs written before the version key was
+// required. Their layout is that of v0.
+func migrateUnsetToV0(rawConfig) error {
+	return nil
+}
+
+// migrateV0ToV1 converts v0 configs, which v1 only extends with group
+// memberships. Their layout is unchanged.
+func migrateV0ToV1(rawConfig) error {
+	return nil
+}
+
+// UnsupportedVersionError is returned when parsing a config whose schema
+// version is unknown to this package.
+type UnsupportedVersionError struct {
+	Version string
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	if versionNumber(e.Version) > versionNumber(CurrentVersion) {
+		return fmt.Sprintf(
+			"samba container config version %q is newer than the"+
+				" supported version %q",
+			e.Version, CurrentVersion)
+	}
+	return fmt.Sprintf(
+		"unknown samba container config version %q", e.Version)
+}
+
+// versionNumber returns the number of a version in the form "vN", or -1
+// if the version is not in that form.
+func versionNumber(v string) int {
+	if !strings.HasPrefix(v, "v") {
+		return -1
+	}
+	n, err := strconv.Atoi(v[1:])
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
+
+// Parse decodes a serialized samba container config and returns it with
+// the schema version it was stored in. Configs of older schema versions
+// are migrated to the current version. Configs of unknown versions are
+// refused with an UnsupportedVersionError. The result is validated.
+func Parse(b []byte) (cc *SambaContainerConfig, stored string, err error) {
+	raw := rawConfig{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, "", fmt.Errorf(
+			"failed to parse samba container config: %w", err)
+	}
+	version := versionUnset
+	migrated := false
+	if v, found := raw[versionKey]; found {
+		s, ok := v.(string)
+		if !ok {
+			return nil, "", fmt.Errorf(
+				"samba container config version is not a string: %v", v)
+		}
+		version = s
+	}
+	stored = version
+	for version != CurrentVersion {
+		m, found := findMigration(version)
+		if !found {
+			return nil, "", &UnsupportedVersionError{Version: version}
+		}
+		if err := m.apply(raw); err != nil {
+			return nil, "", fmt.Errorf(
+				"failed to migrate samba container config from %q to %q: %w",
+				m.from, m.to, err)
+		}
+		raw[versionKey] = m.to
+		version = m.to
+		migrated = true
+	}
+	if migrated {
+		if b, err = json.Marshal(raw); err != nil {
+			return nil, "", err
+		}
+	}
+	cc = New()
+	if err := json.Unmarshal(b, cc); err != nil {
+		return nil, "", fmt.Errorf(
+			"failed to parse samba container config: %w", err)
+	}
+	if err := cc.Validate(); err != nil {
+		return nil, "", err
+	}
+	return cc, stored, nil
+}
+
+func findMigration(from string) (migration, bool) {
+	for _, m := range migrations {
+		if m.from == from {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+// InvalidConfigError lists the structural problems found in a config.
+type InvalidConfigError struct {
+	Problems []string
+}
+
+func (e *InvalidConfigError) Error() string {
+	return "invalid samba container config: " +
+		strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that the config is of a known version supporting the
+// features it uses, that every config section references existing shares
+// and globals, and that group memberships reference existing users and
+// groups.
+func (scc *SambaContainerConfig) Validate() error {
+	var problems []string
+	if !IsKnownVersion(scc.SCCVersion) {
+		problems = append(problems, fmt.Sprintf(
+			"version %q is not supported", scc.SCCVersion))
+	} else if scc.SCCVersion == version0 && scc.HasMemberships() {
+		problems = append(problems, fmt.Sprintf(
+			"group memberships require version %q", version1))
+	}
+	keys := make([]string, 0, len(scc.Configs))
+	for k := range scc.Configs {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		section := scc.Configs[Key(k)]
+		for _, s := range section.Shares {
+			if _, found := scc.Shares[s]; !found {
+				problems = append(problems, fmt.Sprintf(
+					"config %q references missing share %q", k, s))
+			}
+		}
+		for _, g := range section.Globals {
+			if _, found := scc.Globals[g]; !found {
+				problems = append(problems, fmt.Sprintf(
+					"config %q references missing globals %q", k, g))
+			}
+		}
+	}
+	problems = append(problems, scc.userProblems()...)
+	if len(problems) > 0 {
+		return &InvalidConfigError{Problems: problems}
+	}
+	return nil
+}
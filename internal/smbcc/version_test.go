@@ -0,0 +1,86 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbcc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	scc, stored, err := Parse([]byte(json1))
+	require.NoError(t, err)
+	assert.Equal(t, version0, stored)
+	assert.Equal(t, CurrentVersion, scc.SCCVersion)
+	assert.Contains(t, scc.Configs, Key("wbtest"))
+
+	// configs without a version are migrated
+	unversioned := strings.Replace(
+		json1, `"samba-container-config": "v0",`, "", 1)
+	scc2, stored, err := Parse([]byte(unversioned))
+	require.NoError(t, err)
+	assert.Equal(t, versionUnset, stored)
+	assert.Equal(t, scc, scc2)
+}
+
+func TestParseUnsupportedVersion(t *testing.T) {
+	future := strings.Replace(json1, `"v0"`, `"v3"`, 1)
+	_, _, err := Parse([]byte(future))
+	var verr *UnsupportedVersionError
+	require.True(t, errors.As(err, &verr))
+	assert.Equal(t, "v3", verr.Version)
+	assert.Contains(t, err.Error(), "newer than the supported version")
+
+	unknown := strings.Replace(json1, `"v0"`, `"beta"`, 1)
+	_, _, err = Parse([]byte(unknown))
+	require.True(t, errors.As(err, &verr))
+	assert.Contains(t, err.Error(), "unknown samba container config")
+
+	_, _, err = Parse([]byte(`{"samba-container-config": 0}`))
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	scc := New()
+	scc.Globals[NoPrintingKey] = NewNoPrintingGlobals()
+	scc.Shares["share"] = NewSimpleShare("/share")
+	cfg := NewConfigSection("WB1")
+	cfg.Shares = []Key{"share"}
+	cfg.Globals = []Key{NoPrintingKey}
+	scc.Configs["wbtest"] = cfg
+	assert.NoError(t, scc.Validate())
+
+	cfg.Shares = []Key{"share", "other"}
+	cfg.Globals = []Key{NoPrintingKey, "realm"}
+	scc.Configs["wbtest"] = cfg
+	scc.SCCVersion = "v2"
+	err := scc.Validate()
+	var verr *InvalidConfigError
+	require.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Problems, 3)
+	assert.Contains(t, err.Error(), `missing share "other"`)
+	assert.Contains(t, err.Error(), `missing globals "realm"`)
+
+	_, _, err = Parse([]byte(`{
+  "samba-container-config": "v0",
+  "configs": {"c": {"shares": ["nope"]}}
+}`))
+	assert.Error(t, err)
+}
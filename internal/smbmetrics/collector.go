@@ -0,0 +1,251 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbmetrics
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "samba"
+
+// Runner runs a command and returns its standard output.
+type Runner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// ExecRunner runs commands on the local system.
+func ExecRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// Collector periodically gathers the status of the samba server and
+// reports it when scraped. Gathering the status is decoupled from scrapes
+// so that frequent scrapes do not load the server.
+type Collector struct {
+	run       Runner
+	clustered bool
+
+	// textStatus is set when smbstatus does not support JSON output.
+	// versionChecked is set once the version of smbstatus is known.
+	versionChecked bool
+	textStatus     bool
+
+	lock       sync.Mutex
+	status     *SmbStatus
+	locks      *SmbStatus
+	nodes      []CTDBNode
+	lastUpdate time.Time
+	errors     float64
+
+	sessionsDesc     *prometheus.Desc
+	treeConnectsDesc *prometheus.Desc
+	openFilesDesc    *prometheus.Desc
+	locksDesc        *prometheus.Desc
+	nodeHealthyDesc  *prometheus.Desc
+	lastUpdateDesc   *prometheus.Desc
+	errorsDesc       *prometheus.Desc
+}
+
+// NewCollector returns a new Collector. If clustered is true the status of
+// the CTDB nodes is also collected.
+func NewCollector(run Runner, clustered bool) *Collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", name),
+			help, labels, nil)
+	}
+	return &Collector{
+		run:       run,
+		clustered: clustered,
+		sessionsDesc: desc(
+			"sessions", "Number of SMB sessions."),
+		treeConnectsDesc: desc(
+			"tree_connects", "Number of tree connects to a share.", "share"),
+		openFilesDesc: desc(
+			"open_files", "Number of open file handles."),
+		locksDesc: desc(
+			"byte_range_locks", "Number of byte-range locks."),
+		nodeHealthyDesc: desc(
+			"ctdb_node_healthy",
+			"Set to 1 if the CTDB node has no flags set.",
+			"pnn", "address"),
+		lastUpdateDesc: desc(
+			"status_last_update_timestamp_seconds",
+			"Time the status was last gathered successfully."),
+		errorsDesc: desc(
+			"status_update_errors_total",
+			"Number of failures gathering the status."),
+	}
+}
+
+// Update gathers the current status of the server.
+func (c *Collector) Update(ctx context.Context) error {
+	status, locks, nodes, err := c.gather(ctx)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err != nil {
+		c.errors++
+		return err
+	}
+	c.status, c.locks, c.nodes = status, locks, nodes
+	c.lastUpdate = time.Now()
+	return nil
+}
+
+// gather runs the commands reporting the status of the server. It is only
+// called by Update, which must not be called concurrently.
+func (c *Collector) gather(ctx context.Context) (
+	*SmbStatus, *SmbStatus, []CTDBNode, error) {
+	// ---
+	if !c.versionChecked {
+		out, err := c.run(ctx, "smbstatus", "--version")
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		supported, err := SupportsJSON(string(out))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		c.textStatus = !supported
+		c.versionChecked = true
+	}
+	var (
+		status, locks *SmbStatus
+		err           error
+	)
+	if c.textStatus {
+		status, err = c.gatherText(ctx)
+		locks = status
+	} else {
+		status, locks, err = c.gatherJSON(ctx)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !c.clustered {
+		return status, locks, nil, nil
+	}
+	out, err := c.run(ctx, "ctdb", "-X", "status")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	nodes, err := ParseCTDBStatus(string(out))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return status, locks, nodes, nil
+}
+
+func (c *Collector) gatherJSON(ctx context.Context) (
+	*SmbStatus, *SmbStatus, error) {
+	// ---
+	out, err := c.run(ctx, "smbstatus", "--json")
+	if err != nil {
+		return nil, nil, err
+	}
+	status, err := ParseSmbStatus(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err = c.run(ctx, "smbstatus", "--json", "--byterange")
+	if err != nil {
+		return nil, nil, err
+	}
+	locks, err := ParseSmbStatus(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	return status, locks, nil
+}
+
+// gatherText gathers the status from the text output of smbstatus, for
+// versions of samba older than 4.16.
+func (c *Collector) gatherText(ctx context.Context) (*SmbStatus, error) {
+	outs := make([]string, 0, 4)
+	for _, opt := range []string{"-p", "-S", "-L", "-B"} {
+		out, err := c.run(ctx, "smbstatus", opt)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, string(out))
+	}
+	return ParseSmbStatusText(outs[0], outs[1], outs[2], outs[3]), nil
+}
+
+// Run updates the status every interval until the context is done.
+// Errors are passed to onError.
+func (c *Collector) Run(
+	ctx context.Context,
+	interval time.Duration,
+	onError func(error)) {
+	// ---
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Update(ctx); err != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sessionsDesc
+	ch <- c.treeConnectsDesc
+	ch <- c.openFilesDesc
+	ch <- c.locksDesc
+	ch <- c.nodeHealthyDesc
+	ch <- c.lastUpdateDesc
+	ch <- c.errorsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	gauge := func(d *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(
+			d, prometheus.GaugeValue, v, labels...)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.errorsDesc, prometheus.CounterValue, c.errors)
+	if c.status == nil {
+		// nothing was gathered yet
+		return
+	}
+	gauge(c.lastUpdateDesc, float64(c.lastUpdate.Unix()))
+	gauge(c.sessionsDesc, float64(len(c.status.Sessions)))
+	for share, n := range c.status.TreeConnectsByShare() {
+		gauge(c.treeConnectsDesc, float64(n), share)
+	}
+	gauge(c.openFilesDesc, float64(c.status.NumOpens()))
+	gauge(c.locksDesc, float64(c.locks.NumByteRangeLocks()))
+	for _, n := range c.nodes {
+		healthy := 0.0
+		if n.Healthy() {
+			healthy = 1
+		}
+		gauge(c.nodeHealthyDesc, healthy, n.PNN, n.Address)
+	}
+}
@@ -0,0 +1,250 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package smbmetrics collects the status of a running samba server and
+// exposes it as Prometheus metrics.
+package smbmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SmbStatus is the subset of the JSON output of smbstatus used to produce
+// metrics.
+type SmbStatus struct {
+	Sessions       map[string]Session        `json:"sessions"`
+	TreeConnects   map[string]TreeConnect    `json:"tcons"`
+	OpenFiles      map[string]OpenFile       `json:"open_files"`
+	ByteRangeLocks map[string]ByteRangeLocks `json:"byte_range_locks"`
+}
+
+// Session is a SMB session reported by smbstatus.
+type Session struct {
+	SessionID string `json:"session_id"`
+	Username  string `json:"username"`
+	Dialect   string `json:"session_dialect"`
+}
+
+// TreeConnect is a connection to a share reported by smbstatus.
+type TreeConnect struct {
+	Service   string `json:"service"`
+	SessionID string `json:"session_id"`
+}
+
+// OpenFile is a file with one or more opens reported by smbstatus.
+type OpenFile struct {
+	ServicePath string                     `json:"service_path"`
+	Opens       map[string]json.RawMessage `json:"opens"`
+}
+
+// ByteRangeLocks are the byte-range locks held on a file.
+type ByteRangeLocks struct {
+	Locks []json.RawMessage `json:"locks"`
+}
+
+// ParseSmbStatus parses the output of "smbstatus --json".
+func ParseSmbStatus(b []byte) (*SmbStatus, error) {
+	s := &SmbStatus{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("failed to parse smbstatus output: %w", err)
+	}
+	return s, nil
+}
+
+// sambaVersionRE matches the version printed by "smbstatus --version".
+var sambaVersionRE = regexp.MustCompile(`Version (\d+)\.(\d+)`)
+
+// SupportsJSON returns true if the samba version printed by
+// "smbstatus --version" supports the --json option of smbstatus, which
+// was added in samba 4.16.
+func SupportsJSON(version string) (bool, error) {
+	m := sambaVersionRE.FindStringSubmatch(version)
+	if m == nil {
+		return false, fmt.Errorf("unexpected smbstatus version: %q", version)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return major > 4 || (major == 4 && minor >= 16), nil
+}
+
+// pidRE matches the pid column of the text output of smbstatus, which is
+// prefixed by the node number on clustered servers.
+var pidRE = regexp.MustCompile(`^\d+(:\d+)?$`)
+
+// ParseSmbStatusText builds the status from the text output of
+// "smbstatus -p", "smbstatus -S", "smbstatus -L" and "smbstatus -B", for
+// versions of samba without JSON output. The share of a tree connect is
+// the text preceding the pid column, so share names containing a token
+// that looks like a pid are reported incorrectly.
+func ParseSmbStatusText(
+	processes, shares, locks, byteRangeLocks string) *SmbStatus {
+	// ---
+	s := &SmbStatus{
+		Sessions:       map[string]Session{},
+		TreeConnects:   map[string]TreeConnect{},
+		OpenFiles:      map[string]OpenFile{},
+		ByteRangeLocks: map[string]ByteRangeLocks{},
+	}
+	for i, row := range textTableRows(processes) {
+		id := strconv.Itoa(i)
+		s.Sessions[id] = Session{SessionID: id, Username: row[1]}
+	}
+	for i, row := range textTableRows(shares) {
+		for j := 1; j < len(row); j++ {
+			if pidRE.MatchString(row[j]) {
+				id := strconv.Itoa(i)
+				s.TreeConnects[id] = TreeConnect{
+					Service: strings.Join(row[:j], " "),
+				}
+				break
+			}
+		}
+	}
+	for i := range textTableRows(locks) {
+		id := strconv.Itoa(i)
+		s.OpenFiles[id] = OpenFile{
+			Opens: map[string]json.RawMessage{id: nil},
+		}
+	}
+	brl := ByteRangeLocks{}
+	for range textTableRows(byteRangeLocks) {
+		brl.Locks = append(brl.Locks, nil)
+	}
+	s.ByteRangeLocks["all"] = brl
+	return s
+}
+
+// textTableRows returns the fields of the rows of the tables in the text
+// output of smbstatus. A table starts after a line of dashes and ends at
+// the next empty line. Rows with less than two fields are skipped.
+func textTableRows(out string) [][]string {
+	var rows [][]string
+	inTable := false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			inTable = false
+		case strings.Trim(line, "-") == "":
+			inTable = true
+		case inTable:
+			if fields := strings.Fields(line); len(fields) >= 2 {
+				rows = append(rows, fields)
+			}
+		}
+	}
+	return rows
+}
+
+// NumOpens returns the total number of opens of all open files.
+func (s *SmbStatus) NumOpens() int {
+	n := 0
+	for _, f := range s.OpenFiles {
+		n += len(f.Opens)
+	}
+	return n
+}
+
+// NumByteRangeLocks returns the total number of byte-range locks.
+func (s *SmbStatus) NumByteRangeLocks() int {
+	n := 0
+	for _, l := range s.ByteRangeLocks {
+		n += len(l.Locks)
+	}
+	return n
+}
+
+// TreeConnectsByShare returns the number of tree connects of each share.
+func (s *SmbStatus) TreeConnectsByShare() map[string]int {
+	counts := map[string]int{}
+	for _, t := range s.TreeConnects {
+		counts[t.Service]++
+	}
+	return counts
+}
+
+// CTDBNode is the status of a node of the CTDB cluster.
+type CTDBNode struct {
+	PNN     string
+	Address string
+	// Flags lists the names of the flags set on the node, for example
+	// "Disabled" or "Unhealthy".
+	Flags []string
+}
+
+// Healthy returns true if no flag is set on the node.
+func (n CTDBNode) Healthy() bool {
+	return len(n.Flags) == 0
+}
+
+// ctdbInfoColumns are the columns of "ctdb -X status" that do not describe
+// a problem with the node.
+var ctdbInfoColumns = map[string]bool{
+	"Node":            true,
+	"IP":              true,
+	"Current":         true,
+	"ThisNode":        true,
+	"Partial":         true,
+	"PartiallyOnline": true,
+}
+
+// ParseCTDBStatus parses the node table printed by "ctdb -X status". The
+// columns are looked up by name in the header, as their order differs
+// between versions of ctdb.
+func ParseCTDBStatus(out string) ([]CTDBNode, error) {
+	var (
+		header []string
+		nodes  []CTDBNode
+	)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+		fields := strings.Split(strings.Trim(line, "|"), "|")
+		if header == nil {
+			if fields[0] != "Node" {
+				return nil, fmt.Errorf("unexpected ctdb status header: %q", line)
+			}
+			header = fields
+			continue
+		}
+		if len(fields) != len(header) {
+			// the node table is followed by other tables
+			break
+		}
+		node := CTDBNode{}
+		for i, name := range header {
+			switch {
+			case name == "Node":
+				node.PNN = fields[i]
+			case name == "IP":
+				node.Address = fields[i]
+			case ctdbInfoColumns[name]:
+			case fields[i] != "0":
+				node.Flags = append(node.Flags, name)
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	if header == nil {
+		return nil, fmt.Errorf("no node table in ctdb status output")
+	}
+	return nodes, nil
+}
@@ -0,0 +1,283 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smbmetrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const smbstatusJSON = `{
+  "timestamp": "2022-05-10T11:32:18.541296+0000",
+  "version": "4.16.1",
+  "smb_conf": "/etc/samba/smb.conf",
+  "sessions": {
+    "1907311203": {
+      "session_id": "1907311203",
+      "username": "sambauser",
+      "session_dialect": "SMB3_11"
+    },
+    "2251690312": {
+      "session_id": "2251690312",
+      "username": "bob",
+      "session_dialect": "SMB3_11"
+    }
+  },
+  "tcons": {
+    "2531917591": {"service": "share", "session_id": "1907311203"},
+    "3404389932": {"service": "share", "session_id": "2251690312"},
+    "1093187391": {"service": "IPC$", "session_id": "2251690312"}
+  },
+  "open_files": {
+    "/mnt/share/a.txt": {
+      "service_path": "/mnt/share",
+      "opens": {"56/1": {}, "57/2": {}}
+    },
+    "/mnt/share/b.txt": {
+      "service_path": "/mnt/share",
+      "opens": {"56/3": {}}
+    }
+  }
+}`
+
+const smbstatusLocksJSON = `{
+  "version": "4.16.1",
+  "byte_range_locks": {
+    "/mnt/share/a.txt": {"locks": [{}, {}]}
+  }
+}`
+
+const ctdbStatus = `|Node|IP|Disconnected|Unknown|Disabled|Banned|Inactive|Unhealthy|Stopped|Partial|Current|
+|0|10.0.0.1|0|0|0|0|0|0|0|0|Y|
+|1|10.0.0.2|0|0|1|0|0|1|0|0|N|
+`
+
+func TestParseSmbStatus(t *testing.T) {
+	s, err := ParseSmbStatus([]byte(smbstatusJSON))
+	require.NoError(t, err)
+	assert.Len(t, s.Sessions, 2)
+	assert.Equal(t, map[string]int{"share": 2, "IPC$": 1},
+		s.TreeConnectsByShare())
+	assert.Equal(t, 3, s.NumOpens())
+	assert.Equal(t, 0, s.NumByteRangeLocks())
+
+	s, err = ParseSmbStatus([]byte(smbstatusLocksJSON))
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.NumByteRangeLocks())
+
+	_, err = ParseSmbStatus([]byte("Samba version 4.16.1"))
+	assert.Error(t, err)
+}
+
+const smbstatusProcesses = `
+Samba version 4.15.5
+PID     Username     Group        Machine                          Protocol Version  Encryption
+-------------------------------------------------------------------------------------------------
+1234    sambauser    sambauser    10.0.0.5 (ipv4:10.0.0.5:50212)   SMB3_11           -
+1240    bob          bob          10.0.0.6 (ipv4:10.0.0.6:50214)   SMB3_11           -
+`
+
+const smbstatusShares = `
+Service      pid     Machine       Connected at                     Encryption   Signing
+---------------------------------------------------------------------------------------------
+share        1234    10.0.0.5      Tue May 10 11:32:18 AM 2022 UTC  -            -
+Bob's Files  1:1240  10.0.0.6      Tue May 10 11:33:02 AM 2022 UTC  -            -
+IPC$         1240    10.0.0.6      Tue May 10 11:33:01 AM 2022 UTC  -            -
+`
+
+const smbstatusLocks = `
+Locked files:
+Pid     User(ID)  DenyMode   Access    R/W     Oplock      SharePath   Name   Time
+-------------------------------------------------------------------------------------------------
+1234    1000      DENY_NONE  0x120089  RDONLY  LEASE(RWH)  /mnt/share  a.txt 11:32:20
+1240    1001      DENY_NONE  0x120089  RDONLY  LEASE(RWH)  /mnt/share  a.txt 11:33:05
+
+`
+
+const smbstatusNoLocks = `
+No locked files
+
+`
+
+func TestSupportsJSON(t *testing.T) {
+	for version, expected := range map[string]bool{
+		"Version 4.16.1":               true,
+		"Version 4.17.0-GIT-1234abc\n": true,
+		"Version 5.0.0":                true,
+		"Version 4.15.5-Ubuntu":        false,
+		"Version 4.9.5-Debian":         false,
+	} {
+		supported, err := SupportsJSON(version)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, supported, version)
+	}
+	_, err := SupportsJSON("smbstatus: command not found")
+	assert.Error(t, err)
+}
+
+func TestParseSmbStatusText(t *testing.T) {
+	s := ParseSmbStatusText(
+		smbstatusProcesses, smbstatusShares, smbstatusLocks, smbstatusNoLocks)
+	assert.Len(t, s.Sessions, 2)
+	assert.Equal(t,
+		map[string]int{"share": 1, "Bob's Files": 1, "IPC$": 1},
+		s.TreeConnectsByShare())
+	assert.Equal(t, 2, s.NumOpens())
+	assert.Equal(t, 0, s.NumByteRangeLocks())
+
+	s = ParseSmbStatusText("", "", smbstatusNoLocks, `
+Byte range locks:
+Pid        dev:inode       R/W  start     size      SharePath               Name
+--------------------------------------------------------------------------------
+1234       2049:1234       R    0         10        /mnt/share   a.txt
+1234       2049:1234       W    10        10        /mnt/share   a.txt
+`)
+	assert.Len(t, s.Sessions, 0)
+	assert.Equal(t, 0, s.NumOpens())
+	assert.Equal(t, 2, s.NumByteRangeLocks())
+}
+
+func TestCollectorText(t *testing.T) {
+	var cmds []string
+	run := func(_ context.Context, name string, args ...string) ([]byte, error) {
+		cmd := name + " " + strings.Join(args, " ")
+		cmds = append(cmds, cmd)
+		switch cmd {
+		case "smbstatus --version":
+			return []byte("Version 4.15.5\n"), nil
+		case "smbstatus -p":
+			return []byte(smbstatusProcesses), nil
+		case "smbstatus -S":
+			return []byte(smbstatusShares), nil
+		case "smbstatus -L":
+			return []byte(smbstatusLocks), nil
+		case "smbstatus -B":
+			return []byte(""), nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s", cmd)
+	}
+
+	c := NewCollector(run, false)
+	require.NoError(t, c.Update(context.Background()))
+	require.NoError(t, c.Update(context.Background()))
+	// the version is only checked once
+	assert.Equal(t, []string{
+		"smbstatus --version",
+		"smbstatus -p", "smbstatus -S", "smbstatus -L", "smbstatus -B",
+		"smbstatus -p", "smbstatus -S", "smbstatus -L", "smbstatus -B",
+	}, cmds)
+	expected := `
+# HELP samba_open_files Number of open file handles.
+# TYPE samba_open_files gauge
+samba_open_files 2
+# HELP samba_sessions Number of SMB sessions.
+# TYPE samba_sessions gauge
+samba_sessions 2
+`
+	assert.NoError(t, testutil.CollectAndCompare(
+		c, strings.NewReader(expected),
+		"samba_open_files",
+		"samba_sessions"))
+}
+
+func TestParseCTDBStatus(t *testing.T) {
+	nodes, err := ParseCTDBStatus(ctdbStatus)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "0", nodes[0].PNN)
+	assert.Equal(t, "10.0.0.1", nodes[0].Address)
+	assert.True(t, nodes[0].Healthy())
+	assert.False(t, nodes[1].Healthy())
+	assert.Equal(t, []string{"Disabled", "Unhealthy"}, nodes[1].Flags)
+
+	_, err = ParseCTDBStatus("connection to ctdbd failed")
+	assert.Error(t, err)
+}
+
+func TestCollector(t *testing.T) {
+	fail := false
+	run := func(_ context.Context, name string, args ...string) ([]byte, error) {
+		if fail {
+			return nil, fmt.Errorf("failed")
+		}
+		cmd := name + " " + strings.Join(args, " ")
+		switch cmd {
+		case "smbstatus --version":
+			return []byte("Version 4.16.1\n"), nil
+		case "smbstatus --json":
+			return []byte(smbstatusJSON), nil
+		case "smbstatus --json --byterange":
+			return []byte(smbstatusLocksJSON), nil
+		case "ctdb -X status":
+			return []byte(ctdbStatus), nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s", cmd)
+	}
+
+	c := NewCollector(run, true)
+	// only the error counter is reported before the first update
+	assert.Equal(t, 1, testutil.CollectAndCount(c))
+
+	require.NoError(t, c.Update(context.Background()))
+	expected := `
+# HELP samba_byte_range_locks Number of byte-range locks.
+# TYPE samba_byte_range_locks gauge
+samba_byte_range_locks 2
+# HELP samba_ctdb_node_healthy Set to 1 if the CTDB node has no flags set.
+# TYPE samba_ctdb_node_healthy gauge
+samba_ctdb_node_healthy{address="10.0.0.1",pnn="0"} 1
+samba_ctdb_node_healthy{address="10.0.0.2",pnn="1"} 0
+# HELP samba_open_files Number of open file handles.
+# TYPE samba_open_files gauge
+samba_open_files 3
+# HELP samba_sessions Number of SMB sessions.
+# TYPE samba_sessions gauge
+samba_sessions 2
+# HELP samba_tree_connects Number of tree connects to a share.
+# TYPE samba_tree_connects gauge
+samba_tree_connects{share="IPC$"} 1
+samba_tree_connects{share="share"} 2
+`
+	assert.NoError(t, testutil.CollectAndCompare(
+		c, strings.NewReader(expected),
+		"samba_byte_range_locks",
+		"samba_ctdb_node_healthy",
+		"samba_open_files",
+		"samba_sessions",
+		"samba_tree_connects"))
+
+	// a failed update keeps the previous status
+	fail = true
+	assert.Error(t, c.Update(context.Background()))
+	expected = `
+# HELP samba_sessions Number of SMB sessions.
+# TYPE samba_sessions gauge
+samba_sessions 2
+# HELP samba_status_update_errors_total Number of failures gathering the status.
+# TYPE samba_status_update_errors_total counter
+samba_status_update_errors_total 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(
+		c, strings.NewReader(expected),
+		"samba_sessions",
+		"samba_status_update_errors_total"))
+}
@@ -17,20 +17,27 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	goruntime "runtime"
 
 	flag "github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
 	"github.com/samba-in-kubernetes/samba-operator/controllers"
 	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/debugserver"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -52,14 +59,40 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == renderCommand {
+		os.Exit(runRender(os.Args[2:]))
+	}
+
 	confSource := conf.NewSource()
 	var metricsAddr string
+	var debugAddr string
+	var debugCertDir string
+	var configMap string
 	var enableLeaderElection bool
 	flag.StringVar(
 		&metricsAddr,
 		"metrics-addr",
 		":8080",
 		"The address the metric endpoint binds to.")
+	flag.StringVar(
+		&debugAddr,
+		"debug-addr",
+		"",
+		"The address the debug endpoint binds to. "+
+			"The endpoint is disabled if unset.")
+	flag.StringVar(
+		&debugCertDir,
+		"debug-cert-dir",
+		filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs"),
+		"The directory holding the tls.crt and tls.key files the debug "+
+			"endpoint is served with. Defaults to the certificate "+
+			"directory of the webhook server.")
+	flag.StringVar(
+		&configMap,
+		"config-map",
+		"",
+		"The name of a ConfigMap in the working namespace holding "+
+			"configuration values. Changes are applied without a restart.")
 	flag.BoolVar(
 		&enableLeaderElection,
 		"enable-leader-election",
@@ -81,22 +114,41 @@ func main() {
 		os.Exit(1)
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	restConfig := ctrl.GetConfigOrDie()
+	options := ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		Port:               9443,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   "b60bd080.samba.org",
-	})
+		// secrets are read directly, so that only the metadata of the
+		// secrets watched by the controllers is cached
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}},
+	}
+	if err := restrictNamespaces(restConfig, &options); err != nil {
+		setupLog.Error(err, "unable to determine watched namespaces")
+		os.Exit(1)
+	}
+	mgr, err := ctrl.NewManager(restConfig, options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controllers.SmbShareReconciler{
+	shareReconciler := &controllers.SmbShareReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("SmbShare"),
-	}).SetupWithManager(mgr); err != nil {
+	}
+	if err = (&controllers.ConfigReloader{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("config"),
+		Source:    confSource,
+		ConfigMap: configMap,
+	}).SetupWithManager(mgr, shareReconciler); err != nil {
+		setupLog.Error(err, "unable to set up configuration reloading")
+		os.Exit(1)
+	}
+	if err = shareReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(
 			err,
 			"unable to create controller",
@@ -125,6 +177,20 @@ func main() {
 	}
 	// +kubebuilder:scaffold:builder
 
+	if debugAddr != "" {
+		err = mgr.Add(&debugserver.Server{
+			Addr:    debugAddr,
+			CertDir: debugCertDir,
+			Client:  mgr.GetClient(),
+			Scheme:  mgr.GetScheme(),
+			Log:     ctrl.Log.WithName("debug"),
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to set up debug endpoint")
+			os.Exit(1)
+		}
+	}
+
 	setupLog.Info("starting manager",
 		"Version", Version,
 		"CommitID", CommitID,
@@ -135,3 +201,28 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// restrictNamespaces limits the cache of the manager to the namespaces
+// chosen by the operator configuration, if any.
+func restrictNamespaces(restConfig *rest.Config, options *ctrl.Options) error {
+	reader, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	namespaces, err := controllers.WatchedNamespaces(
+		context.Background(), reader, conf.Get())
+	if err != nil {
+		return err
+	}
+	switch len(namespaces) {
+	case 0:
+		setupLog.Info("watching all namespaces")
+	case 1:
+		setupLog.Info("watching namespace", "namespace", namespaces[0])
+		options.Namespace = namespaces[0]
+	default:
+		setupLog.Info("watching namespaces", "namespaces", namespaces)
+		options.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	flag "github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	sambaoperatorv1alpha1 "github.com/samba-in-kubernetes/samba-operator/api/v1alpha1"
+	"github.com/samba-in-kubernetes/samba-operator/internal/conf"
+	"github.com/samba-in-kubernetes/samba-operator/internal/resources"
+)
+
+// renderCommand is the name of the subcommand printing what the operator
+// would deploy for shares read from files.
+const renderCommand = "render"
+
+// runRender implements the render subcommand and returns the exit code.
+func runRender(args []string) int {
+	confSource := conf.NewSource()
+	var (
+		files         []string
+		namespace     string
+		manifestsOnly bool
+	)
+	fs := flag.NewFlagSet(renderCommand, flag.ContinueOnError)
+	fs.StringSliceVarP(
+		&files,
+		"filename",
+		"f",
+		nil,
+		"Files holding SmbShare, SmbSecurityConfig and SmbCommonConfig "+
+			"resources. Use - to read from standard input.")
+	fs.StringVar(
+		&namespace,
+		"namespace",
+		"default",
+		"The namespace of resources that do not specify one.")
+	fs.BoolVar(
+		&manifestsOnly,
+		"manifests-only",
+		false,
+		"Only print the manifests, so that they can be applied.")
+	fs.AddFlagSet(confSource.Flags())
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr,
+			"Usage: %s %s -f FILE...\n\n"+
+				"Print the samba configuration and the resources the operator "+
+				"would create for the shares in FILE.\n"+
+				"The default values declared by the CRDs are applied to the "+
+				"resources in FILE, as the API server does.\n\n",
+			os.Args[0], renderCommand)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	in, err := readRenderInput(files, namespace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	cfg, err := confSource.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if cfg.WorkingNamespace == "" {
+		// there is no operator pod to take the namespace from
+		cfg.WorkingNamespace = namespace
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	rendered, err := resources.Render(cfg, in)
+	if err == nil {
+		err = printRendered(os.Stdout, rendered, manifestsOnly)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readRenderInput reads the resources of the operator from YAML or JSON
+// files. Resources of other kinds are skipped.
+func readRenderInput(
+	files []string, namespace string) (resources.RenderInput, error) {
+	// ---
+	in := resources.RenderInput{}
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	for _, name := range files {
+		f := os.Stdin
+		if name != "-" {
+			var err error
+			if f, err = os.Open(name); err != nil {
+				return in, err
+			}
+		}
+		reader := k8syaml.NewYAMLReader(bufio.NewReader(f))
+		for {
+			doc, err := reader.Read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				f.Close()
+				return in, fmt.Errorf("%s: %w", name, err)
+			}
+			if isEmptyDocument(doc) {
+				continue
+			}
+			doc = withDefaults(doc)
+			obj, gvk, err := decoder.Decode(doc, nil, nil)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: skipping document: %v\n", name, err)
+				continue
+			}
+			switch o := obj.(type) {
+			case *sambaoperatorv1alpha1.SmbShare:
+				setDefaultNamespace(&o.Namespace, namespace)
+				in.Shares = append(in.Shares, o)
+			case *sambaoperatorv1alpha1.SmbSecurityConfig:
+				setDefaultNamespace(&o.Namespace, namespace)
+				in.SecurityConfigs = append(in.SecurityConfigs, o)
+			case *sambaoperatorv1alpha1.SmbCommonConfig:
+				setDefaultNamespace(&o.Namespace, namespace)
+				in.CommonConfigs = append(in.CommonConfigs, o)
+			default:
+				fmt.Fprintf(os.Stderr, "%s: skipping %s\n", name, gvk.Kind)
+			}
+		}
+		f.Close()
+	}
+	return in, nil
+}
+
+// withDefaults returns the document with the default values declared by
+// the CRDs applied, as the API server would store it. Documents that can
+// not be parsed are returned unchanged, for the decoder to report.
+func withDefaults(doc []byte) []byte {
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal(doc, &obj); err != nil {
+		return doc
+	}
+	resources.ApplyDefaults(obj)
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return doc
+	}
+	return b
+}
+
+func isEmptyDocument(doc []byte) bool {
+	var v interface{}
+	return yaml.Unmarshal(doc, &v) == nil && v == nil
+}
+
+func setDefaultNamespace(ns *string, namespace string) {
+	if *ns == "" {
+		*ns = namespace
+	}
+}
+
+// printRendered writes the container config, smb.conf and manifests of
+// each rendered share. The manifests form a YAML stream.
+func printRendered(
+	w io.Writer,
+	rendered []resources.RenderedShare,
+	manifestsOnly bool) error {
+	// ---
+	for _, r := range rendered {
+		if !manifestsOnly {
+			cc, err := json.MarshalIndent(r.ContainerConfig, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "# SmbShare %s/%s\n", r.Share.Namespace, r.Share.Name)
+			fmt.Fprintf(w, "# config.json:\n%s\n", cc)
+			fmt.Fprintf(w, "# smb.conf:\n%s", r.SmbConf)
+		}
+		for _, o := range r.Objects {
+			b, err := yaml.Marshal(o)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "---\n%s", b)
+		}
+	}
+	return nil
+}
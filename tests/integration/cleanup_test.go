@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -30,6 +31,8 @@ type resourceSnapshot struct {
 
 	deployments  *appsv1.DeploymentList
 	statefulSets *appsv1.StatefulSetList
+
+	podDisruptionBudgets *policyv1.PodDisruptionBudgetList
 }
 
 type ShareCreateDeleteSuite struct {
@@ -139,6 +142,9 @@ func (s *ShareCreateDeleteSuite) getCurrentResources() resourceSnapshot {
 	rs.statefulSets, err = s.tc.Clientset().AppsV1().
 		StatefulSets(s.destNamespace).List(ctx, opts)
 	require.NoError(err)
+	rs.podDisruptionBudgets, err = s.tc.Clientset().PolicyV1().
+		PodDisruptionBudgets(s.destNamespace).List(ctx, opts)
+	require.NoError(err)
 
 	return rs
 }
@@ -162,6 +168,9 @@ func (s *ShareCreateDeleteSuite) TestCreateAndDelete() {
 		len(rs1.deployments.Items), len(existing.deployments.Items))
 	require.GreaterOrEqual(
 		len(rs1.statefulSets.Items), len(existing.statefulSets.Items))
+	require.Greater(
+		len(rs1.podDisruptionBudgets.Items),
+		len(existing.podDisruptionBudgets.Items))
 
 	ctx, cancel := context.WithDeadline(
 		context.TODO(),
@@ -209,6 +218,9 @@ func (s *ShareCreateDeleteSuite) TestCreateAndDelete() {
 		len(rs2.deployments.Items), len(existing.deployments.Items))
 	require.Equal(
 		len(rs2.statefulSets.Items), len(existing.statefulSets.Items))
+	require.Equal(
+		len(rs2.podDisruptionBudgets.Items),
+		len(existing.podDisruptionBudgets.Items))
 }
 
 func allShareCreateDeleteSuites() map[string]suite.TestingSuite {